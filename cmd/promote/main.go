@@ -0,0 +1,46 @@
+// Promote an existing account to admin, to bootstrap the first admin. Sign up the account first,
+// then run it where the service config is, e.g. in the container:
+//
+//	go run ./cmd/promote -username alice
+//
+// Admins then manage the other roles with /admin/user/role/:id
+package main
+
+import (
+	"context"
+	"emvn/config"
+	"emvn/consts"
+	"emvn/database/nosql/mongodb"
+	user_repository "emvn/internal/repository/user"
+	"flag"
+	"log"
+)
+
+func main() {
+	username := flag.String("username", "", "username of the account to promote")
+	role := flag.String("role", string(consts.UserRoleAdmin), "role to give: admin, curator or listener")
+	flag.Parse()
+	if *username == "" {
+		log.Fatal("-username is required")
+	}
+	if !consts.UserRole(*role).IsValid() {
+		log.Fatalf("unknown role %q", *role)
+	}
+
+	config.InitConfig()
+	ctx := context.Background()
+	mongodb.InitClient(ctx)
+	user_repository.InitUserRepository(mongodb.MongoDBClient())
+
+	user, err := user_repository.UserRepository().GetUserByUsername(ctx, *username)
+	if err != nil {
+		log.Fatalf("get %s: %s", *username, err)
+	}
+	if user.ID == "" {
+		log.Fatalf("no account %s, sign it up first", *username)
+	}
+	if _, err := user_repository.UserRepository().UpdateRole(ctx, user.ID, consts.UserRole(*role)); err != nil {
+		log.Fatalf("promote %s: %s", *username, err)
+	}
+	log.Printf("%s is now %s", *username, *role)
+}
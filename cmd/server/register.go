@@ -1,26 +1,131 @@
 package server
 
 import (
+	"emvn/config"
 	"emvn/database/nosql/mongodb"
+	apikey_repository "emvn/internal/repository/api_key"
 	musictrack_repository "emvn/internal/repository/music_track"
 	playlist_repository "emvn/internal/repository/playlist"
+	token_repository "emvn/internal/repository/token"
 	user_repository "emvn/internal/repository/user"
+	apikey_usecase "emvn/internal/usecase/api_key"
 	auth_usecase "emvn/internal/usecase/auth"
 	musictrack_usecase "emvn/internal/usecase/music_track"
 	playlist_usecase "emvn/internal/usecase/playlist"
+	user_usecase "emvn/internal/usecase/user"
+	"emvn/middlewares"
+	"emvn/pkg/health"
+	"emvn/pkg/idempotency"
+	idempotency_mongodb "emvn/pkg/idempotency/mongodb"
+	"emvn/pkg/loginguard"
+	loginguard_mongodb "emvn/pkg/loginguard/mongodb"
+	"emvn/pkg/notifier"
+	"emvn/pkg/oidc"
+	"emvn/pkg/password"
+	"emvn/pkg/ratelimit"
+	"emvn/pkg/revocation"
+	revocation_mongodb "emvn/pkg/revocation/mongodb"
+	"emvn/pkg/storage"
 	"emvn/pkg/storage/local"
+	"log"
+	"time"
 )
 
 func Register() {
 	noSqlDB := mongodb.MongoDBClient()
-	localStorage := local.Storage()
+	fileStorage := storage.Instrument(local.Storage())
+	revocationStore := revocation.NewCachedStore(
+		revocation_mongodb.NewStore(noSqlDB),
+		time.Duration(config.GetConfig().Auth.RevocationCacheTime)*time.Second,
+	)
+	userNotifier, err := notifier.New(config.GetConfig().Notifier.Type, config.GetConfig().Notifier.FilePath)
+	if err != nil {
+		log.Fatalf("notifier: %s\n", err)
+	}
+
+	guardCfg := config.GetConfig().Auth.LoginGuard
+	loginGuard := loginguard.NewGuard(loginguard_mongodb.NewStore(noSqlDB),
+		loginguard.Policy{
+			MaxFailures: guardCfg.MaxUsernameFailures,
+			Lockout:     time.Duration(guardCfg.LockoutMinute) * time.Minute,
+			Window:      time.Duration(guardCfg.FailureWindowMinute) * time.Minute,
+			BaseDelay:   time.Duration(guardCfg.BaseDelayMillisecond) * time.Millisecond,
+			MaxDelay:    time.Duration(guardCfg.MaxDelaySecond) * time.Second,
+		},
+		loginguard.Policy{
+			MaxFailures: guardCfg.MaxIPFailures,
+			Lockout:     time.Duration(guardCfg.LockoutMinute) * time.Minute,
+			Window:      time.Duration(guardCfg.FailureWindowMinute) * time.Minute,
+			BaseDelay:   time.Duration(guardCfg.BaseDelayMillisecond) * time.Millisecond,
+			MaxDelay:    time.Duration(guardCfg.MaxDelaySecond) * time.Second,
+		},
+	)
+
+	hashCfg := config.GetConfig().Auth.PasswordHash
+	passwordHasher, err := password.New(hashCfg.Algorithm,
+		password.Argon2id{
+			Memory:      hashCfg.Argon2Memory,
+			Iterations:  hashCfg.Argon2Iterations,
+			Parallelism: hashCfg.Argon2Parallelism,
+		},
+		password.Bcrypt{Cost: hashCfg.BcryptCost},
+	)
+	if err != nil {
+		log.Fatalf("password hasher: %s\n", err)
+	}
+
+	rateLimitStore, err := ratelimit.New(config.GetConfig().RateLimit.Store)
+	if err != nil {
+		log.Fatalf("rate limit: %s\n", err)
+	}
+	middlewares.InitRateLimiter(rateLimitStore)
+
+	var idempotencyStore idempotency.Store
+	if storeType := config.GetConfig().Idempotency.Store; storeType == idempotency.StoreMongoDB {
+		idempotencyStore = idempotency_mongodb.NewStore(noSqlDB)
+	} else if idempotencyStore, err = idempotency.New(storeType); err != nil {
+		log.Fatalf("idempotency: %s\n", err)
+	}
+	middlewares.InitIdempotency(idempotencyStore)
+
+	// The readiness probe checks the storage itself, not through Instrument, to keep it out of the storage metrics
+	health.InitChecker(time.Duration(config.GetConfig().Health.CheckTimeoutSecond)*time.Second,
+		health.MongoCheck(noSqlDB.Ping),
+		health.StorageCheck(local.Storage()),
+	)
+
+	var oidcProvider *oidc.Provider
+	if oidcCfg := config.GetConfig().OIDC; oidcCfg.Enabled {
+		oidcProvider = oidc.NewProvider(oidc.Config{
+			Issuer:       oidcCfg.Issuer,
+			ClientID:     oidcCfg.ClientID,
+			ClientSecret: oidcCfg.ClientSecret,
+			RedirectURL:  oidcCfg.RedirectURL,
+			Scopes:       oidcCfg.Scopes,
+		})
+	}
 
 	user_repository.InitUserRepository(noSqlDB)
-	auth_usecase.InitAuthUsecase(user_repository.UserRepository())
+	token_repository.InitTokenRepository(noSqlDB)
+	apikey_repository.InitAPIKeyRepository(noSqlDB)
+	apikey_usecase.InitAPIKeyUsecase(apikey_repository.APIKeyRepository(), user_repository.UserRepository())
+	auth_usecase.InitAuthUsecase(user_repository.UserRepository(), token_repository.TokenRepository(), revocationStore, oidcProvider, userNotifier, loginGuard, passwordHasher)
 
-	musictrack_repository.InitMusicTrackRepository(noSqlDB, localStorage)
+	musictrack_repository.InitMusicTrackRepository(noSqlDB, fileStorage)
 	musictrack_usecase.InitMusicTrackUsecase(musictrack_repository.MusicTrackRepository())
 
 	playlist_repository.InitPlaylistRepository(noSqlDB)
-	playlist_usecase.InitPlaylistUsecase(playlist_repository.PlaylistRepository(), musictrack_repository.MusicTrackRepository())
+	playlist_usecase.InitPlaylistUsecase(playlist_repository.PlaylistRepository(), musictrack_repository.MusicTrackRepository(), user_repository.UserRepository())
+
+	// Deleting an account cascades to the data of every other repository
+	user_usecase.InitUserUsecase(
+		user_repository.UserRepository(),
+		playlist_repository.PlaylistRepository(),
+		musictrack_repository.MusicTrackRepository(),
+		token_repository.TokenRepository(),
+		apikey_repository.APIKeyRepository(),
+		revocationStore,
+		loginGuard,
+		passwordHasher,
+	)
 }
@@ -1,13 +1,24 @@
 package server
 
 import (
+	"emvn/config"
+	"emvn/consts"
+	apikey_controller "emvn/internal/controller/api_key"
 	auth_controller "emvn/internal/controller/auth"
+	health_controller "emvn/internal/controller/health"
 	musictrack_controller "emvn/internal/controller/music_track"
 	playlist_controller "emvn/internal/controller/playlist"
+	user_controller "emvn/internal/controller/user"
+	apikey_usecase "emvn/internal/usecase/api_key"
 	auth_usecase "emvn/internal/usecase/auth"
 	musictrack_usecase "emvn/internal/usecase/music_track"
 	playlist_usecase "emvn/internal/usecase/playlist"
+	user_usecase "emvn/internal/usecase/user"
 	"emvn/middlewares"
+	"emvn/pkg/health"
+	"emvn/pkg/metrics"
+	"log"
+	"time"
 
 	doc "emvn/docs"
 
@@ -19,45 +30,147 @@ import (
 func InitHandler() *gin.Engine {
 	// Init gin
 	r := gin.Default()
+	// X-Forwarded-For is sent by anyone, it is only trusted from the configured proxies.
+	// Otherwise the client IP of the sign in guard and the rate limits is the peer address
+	if err := r.SetTrustedProxies(config.GetConfig().Server.TrustedProxies); err != nil {
+		log.Fatalf("trusted proxies: %s\n", err)
+	}
+	// Handlers pass the gin context to the usecases, let it fall back to the request context and its logger
+	r.ContextWithFallback = true
 
 	// Add middlewares
+	r.Use(middlewares.RequestIDMiddleware())
+	r.Use(middlewares.TracingMiddleware())
 	r.Use(middlewares.LogMiddleware())
+	r.Use(middlewares.MetricsMiddleware())
 	r.Use(middlewares.CORSMiddleware())
+	r.Use(middlewares.CompressMiddleware())
+	r.Use(middlewares.CacheControlMiddleware())
 	r.Use(middlewares.ResponseMiddleware())
 
 	// Add routes
+	// Route groups are rate limited per user after AuthMiddleware, or per client IP for the public ones
 
-	// Health check
-	r.GET("/", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "OK",
-		})
-	})
+	// Health check, / is kept for the existing monitors
+	healthController := health_controller.NewController(health.HealthChecker())
+	r.GET("/", healthController.Root)
+	r.GET("/healthz", healthController.Liveness)
+	r.GET("/readyz", healthController.Readiness)
 
 	authController := auth_controller.NewController(auth_usecase.AuthUsecase())
 
-	authGroup := r.Group("/auth")
+	authGroup := r.Group("/auth", middlewares.RateLimitMiddleware("auth"))
 	authGroup.POST("/signup", authController.SignUp)
 	authGroup.POST("/signin", authController.SignIn)
+	authGroup.POST("/refresh", authController.Refresh)
+	authGroup.POST("/logout", middlewares.AuthMiddleware(), authController.Logout)
+	authGroup.POST("/logout_all", middlewares.AuthMiddleware(), authController.LogoutAll)
+	// API keys have no role, only a user session can change the password
+	authGroup.POST("/password/change", middlewares.AuthMiddleware(), middlewares.RequireRole(consts.UserRoleAdmin, consts.UserRoleCurator, consts.UserRoleListener), authController.ChangePassword)
+	authGroup.POST("/password/forgot", authController.ForgotPassword)
+	authGroup.POST("/password/reset", authController.ResetPassword)
+	authGroup.GET("/oidc/login", authController.OIDCLogin)
+	authGroup.GET("/oidc/callback", authController.OIDCCallback)
+	r.GET("/.well-known/jwks.json", authController.JWKS)
+
+	apiKeyController := apikey_controller.NewController(apikey_usecase.APIKeyUsecase())
+
+	// API keys are managed with a user session only, a key has no role so it can not create other keys
+	apiKeyGroup := r.Group("/api_key", middlewares.AuthMiddleware(), middlewares.RateLimitMiddleware("api_key"), middlewares.RequireRole(consts.UserRoleAdmin, consts.UserRoleCurator, consts.UserRoleListener))
+	apiKeyGroup.POST("/create", apiKeyController.Create)
+	apiKeyGroup.GET("/list", apiKeyController.List)
+	apiKeyGroup.DELETE("/revoke/:id", apiKeyController.Revoke)
 
 	mucisTrackController := musictrack_controller.NewController(musictrack_usecase.MusicTrackUsecase())
-
-	musicTrackGroup := r.Group("/music_track", middlewares.AuthMiddleware())
-	musicTrackGroup.POST("/create", mucisTrackController.Create)
-	musicTrackGroup.POST("/upload", mucisTrackController.UploadTrack)
-	musicTrackGroup.GET("/get/:id", mucisTrackController.Get)
-	musicTrackGroup.PUT("/update/:id", mucisTrackController.Update)
-	musicTrackGroup.DELETE("/delete/:id", mucisTrackController.Delete)
-	musicTrackGroup.GET("/search", mucisTrackController.Search)
-
 	playlistController := playlist_controller.NewController(playlist_usecase.PlaylistUsecase())
 
-	playlistGroup := r.Group("/playlist", middlewares.AuthMiddleware())
-	playlistGroup.POST("/create", playlistController.Create)
-	playlistGroup.GET("/get/:id", playlistController.Get)
-	playlistGroup.PUT("/update/:id", playlistController.Update)
-	playlistGroup.DELETE("/delete/:id", playlistController.Delete)
-	playlistGroup.GET("/search", playlistController.Search)
+	// The RPC style routes of tracks and playlists are replaced by /v1, they keep working with the Deprecation headers
+	legacyCfg := config.GetConfig().LegacyAPI
+	deprecated := middlewares.DeprecationMiddleware(map[string]string{
+		"/music_track/create":     "/v1/tracks",
+		"/music_track/search":     "/v1/tracks",
+		"/music_track/get/:id":    "/v1/tracks/:id",
+		"/music_track/update/:id": "/v1/tracks/:id",
+		"/music_track/delete/:id": "/v1/tracks/:id",
+		"/music_track/stream/:id": "/v1/tracks/:id/audio",
+		"/playlist/create":        "/v1/playlists",
+		"/playlist/search":        "/v1/playlists",
+		"/playlist/get/:id":       "/v1/playlists/:id",
+		"/playlist/update/:id":    "/v1/playlists/:id",
+		"/playlist/delete/:id":    "/v1/playlists/:id",
+	}, legacyDate("deprecated_at", legacyCfg.DeprecatedAt), legacyDate("sunset_at", legacyCfg.SunsetAt))
+
+	// Everyone can read tracks, only curators and admins can write them
+	// Creates and uploads can be retried with an Idempotency-Key, like the playlist creates
+	musicTrackGroup := r.Group("/music_track", middlewares.AuthMiddleware(), middlewares.RateLimitMiddleware("music_track"), deprecated)
+	musicTrackGroup.POST("/create", middlewares.RequirePermission(consts.PermissionTracksWrite), middlewares.IdempotencyMiddleware(), mucisTrackController.Create)
+	musicTrackGroup.POST("/upload", middlewares.RequirePermission(consts.PermissionTracksWrite), middlewares.IdempotencyMiddleware(), mucisTrackController.UploadTrack)
+	musicTrackGroup.GET("/get/:id", middlewares.RequirePermission(consts.PermissionTracksRead), mucisTrackController.Get)
+	musicTrackGroup.GET("/stream/:id", middlewares.RequirePermission(consts.PermissionTracksRead), mucisTrackController.Stream)
+	musicTrackGroup.PUT("/update/:id", middlewares.RequirePermission(consts.PermissionTracksWrite), mucisTrackController.Update)
+	musicTrackGroup.DELETE("/delete/:id", middlewares.RequirePermission(consts.PermissionTracksWrite), mucisTrackController.Delete)
+	musicTrackGroup.GET("/search", middlewares.RequirePermission(consts.PermissionTracksRead), mucisTrackController.Search)
+
+	// API keys only reach playlists when they are granted the playlist scopes
+	playlistGroup := r.Group("/playlist", middlewares.AuthMiddleware(), middlewares.RateLimitMiddleware("playlist"), deprecated)
+	playlistGroup.POST("/create", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), middlewares.IdempotencyMiddleware(), playlistController.Create)
+	playlistGroup.GET("/get/:id", middlewares.RequirePermission(consts.PermissionPlaylistsRead), playlistController.Get)
+	playlistGroup.PUT("/update/:id", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.Update)
+	playlistGroup.DELETE("/delete/:id", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.Delete)
+	playlistGroup.GET("/search", middlewares.RequirePermission(consts.PermissionPlaylistsRead), playlistController.Search)
+	playlistGroup.POST("/share/:id", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.CreateShare)
+	playlistGroup.GET("/share/:id", middlewares.RequirePermission(consts.PermissionPlaylistsRead), playlistController.ListShares)
+	playlistGroup.DELETE("/share/:id/:token", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.RevokeShare)
+	playlistGroup.POST("/collaborator/:id", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.AddCollaborator)
+	playlistGroup.DELETE("/collaborator/:id/:uid", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.RemoveCollaborator)
+	playlistGroup.POST("/freeze/:id", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.Freeze)
+	playlistGroup.GET("/:id/export", middlewares.RequirePermission(consts.PermissionPlaylistsRead), playlistController.Export)
+	playlistGroup.POST("/import", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), middlewares.IdempotencyMiddleware(), playlistController.Import)
+	playlistGroup.POST("/fork/:id", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), middlewares.IdempotencyMiddleware(), playlistController.Fork)
+	playlistGroup.GET("/forks/:id", middlewares.RequirePermission(consts.PermissionPlaylistsRead), playlistController.ListForks)
+	playlistGroup.POST("/sync/:id", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.SyncFork)
+
+	// Resource oriented routes, with 201 and Location on create and 204 on delete. They share the rate limits of the legacy groups.
+	// Every write accepts an Idempotency-Key
+	v1Group := r.Group("/v1")
+	v1TrackGroup := v1Group.Group("/tracks", middlewares.AuthMiddleware(), middlewares.RateLimitMiddleware("music_track"), middlewares.IdempotencyMiddleware(), middlewares.RESTMiddleware())
+	v1TrackGroup.GET("", middlewares.RequirePermission(consts.PermissionTracksRead), mucisTrackController.Search)
+	v1TrackGroup.POST("", middlewares.RequirePermission(consts.PermissionTracksWrite), mucisTrackController.Create)
+	v1TrackGroup.GET("/:id", middlewares.RequirePermission(consts.PermissionTracksRead), mucisTrackController.Get)
+	v1TrackGroup.PATCH("/:id", middlewares.RequirePermission(consts.PermissionTracksWrite), mucisTrackController.Patch)
+	v1TrackGroup.DELETE("/:id", middlewares.RequirePermission(consts.PermissionTracksWrite), mucisTrackController.Delete)
+	v1TrackGroup.GET("/:id/audio", middlewares.RequirePermission(consts.PermissionTracksRead), mucisTrackController.Stream)
+
+	v1PlaylistGroup := v1Group.Group("/playlists", middlewares.AuthMiddleware(), middlewares.RateLimitMiddleware("playlist"), middlewares.IdempotencyMiddleware(), middlewares.RESTMiddleware())
+	v1PlaylistGroup.GET("", middlewares.RequirePermission(consts.PermissionPlaylistsRead), playlistController.Search)
+	v1PlaylistGroup.POST("", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.Create)
+	v1PlaylistGroup.GET("/:id", middlewares.RequirePermission(consts.PermissionPlaylistsRead), playlistController.Get)
+	v1PlaylistGroup.PATCH("/:id", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.Patch)
+	v1PlaylistGroup.DELETE("/:id", middlewares.RequirePermission(consts.PermissionPlaylistsWrite), playlistController.Delete)
+
+	userController := user_controller.NewController(user_usecase.UserUsecase())
+
+	adminGroup := r.Group("/admin", middlewares.AuthMiddleware(), middlewares.RateLimitMiddleware("admin"), middlewares.RequireRole(consts.UserRoleAdmin))
+	adminGroup.GET("/user/list", middlewares.RequirePermission(consts.PermissionUsersManage), userController.ListUsers)
+	adminGroup.PUT("/user/role/:id", middlewares.RequirePermission(consts.PermissionUsersManage), userController.SetRole)
+	adminGroup.POST("/user/unlock/:id", middlewares.RequirePermission(consts.PermissionUsersManage), userController.Unlock)
+
+	// Self service, API keys have no role so they can not manage the account
+	meGroup := r.Group("/me", middlewares.AuthMiddleware(), middlewares.RateLimitMiddleware("me"), middlewares.RequireRole(consts.UserRoleAdmin, consts.UserRoleCurator, consts.UserRoleListener))
+	meGroup.GET("", userController.GetMe)
+	meGroup.PATCH("", userController.UpdateMe)
+	meGroup.DELETE("", userController.DeleteMe)
+	meGroup.GET("/playlists", userController.ListMyPlaylists)
+	meGroup.GET("/tracks", userController.ListMyTracks)
+
+	// Public read-only routes, resolved by share token
+	sharedGroup := r.Group("/shared", middlewares.RateLimitMiddleware("shared"))
+	sharedGroup.GET("/playlist/:token", playlistController.GetShared)
+
+	// Without an admin port, scrapers need the metrics token
+	if metricsCfg := config.GetConfig().Metrics; metricsCfg.Address == "" && metricsCfg.Token != "" {
+		r.GET("/metrics", middlewares.MetricsTokenMiddleware(metricsCfg.Token), gin.WrapH(metrics.Handler()))
+	}
 
 	// Swagger
 	doc.SwaggerInfo.Title = "EMVN API"
@@ -67,3 +180,15 @@ func InitHandler() *gin.Engine {
 
 	return r
 }
+
+// legacyDate parses a date of the legacy_api config, zero when it is not set
+func legacyDate(key string, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		log.Fatalf("legacy_api.%s: %s\n", key, err)
+	}
+	return t
+}
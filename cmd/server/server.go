@@ -4,13 +4,18 @@ import (
 	"context"
 	"emvn/config"
 	"emvn/database/nosql/mongodb"
+	"emvn/pkg/health"
 	"emvn/pkg/logger"
+	"emvn/pkg/metrics"
 	"emvn/pkg/storage/local"
+	"emvn/pkg/tracing"
 	"emvn/pkg/validator"
+	"emvn/utility"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -22,9 +27,24 @@ func StartServer() {
 	logger.NewLogger(cfg.Log)
 	ctx := context.Background()
 
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: cfg.Tracing.ServiceName,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		FilePath:    cfg.Tracing.FilePath,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("tracing: %s\n", err)
+	}
+
 	mongodb.InitClient(ctx)
 	local.InitLocalStorage()
 	validator.InitValidator()
+	if err := utility.InitJWTKeys(cfg.Auth); err != nil {
+		log.Fatalf("jwt keys: %s\n", err)
+	}
 
 	// Register all dependencies
 	Register()
@@ -42,18 +62,47 @@ func StartServer() {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server with
+	// Metrics on the admin port, apart from the API
+	var adminSrv *http.Server
+	if cfg.Metrics.Address != "" {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("/metrics", metrics.Handler())
+		adminSrv = &http.Server{
+			Addr:    cfg.Metrics.Address,
+			Handler: adminMux,
+		}
+		go func() {
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("admin listen: %s\n", err)
+			}
+		}()
+	}
+
+	// Wait for interrupt signal, or the SIGTERM of the orchestrator, to gracefully shutdown the server with
 	// a timeout of 5 seconds.
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutdown Server ...")
 
+	// Fail the readiness probe first, the load balancers drain the instance while it still serves
+	health.HealthChecker().Drain()
+	time.Sleep(time.Duration(cfg.Health.DrainSecond) * time.Second)
+
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server Shutdown:", err)
 	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			log.Fatal("Admin Server Shutdown:", err)
+		}
+	}
+	// Flush the spans of the last requests
+	if err := shutdownTracing(ctx); err != nil {
+		log.Println("Tracing Shutdown:", err)
+	}
 	<-ctx.Done()
 	log.Println("Server shutdown gracefully.")
 }
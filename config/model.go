@@ -1,14 +1,29 @@
 package config
 
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Log      LogConfig      `yaml:"log"`
-	Auth     AuthConfig     `yaml:"auth"`
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Log         LogConfig         `yaml:"log"`
+	Auth        AuthConfig        `yaml:"auth"`
+	OIDC        OIDCConfig        `yaml:"oidc"`
+	Notifier    NotifierConfig    `yaml:"notifier"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	HTTPCache   HTTPCacheConfig   `yaml:"http_cache"`
+	Compression CompressionConfig `yaml:"compression"`
+	Health      HealthConfig      `yaml:"health"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	LegacyAPI   LegacyAPIConfig   `yaml:"legacy_api"`
 }
 
 type ServerConfig struct {
 	Port string `yaml:"port"`
+	// Scheme and host the clients reach the API at, e.g. behind a proxy. Exported playlists have relative URLs without it
+	PublicURL string `yaml:"public_url"`
+	// IPs or CIDRs of the proxies whose X-Forwarded-For is trusted. Empty trusts none, the client IP is the peer address
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type DatabaseConfig struct {
@@ -17,10 +32,150 @@ type DatabaseConfig struct {
 }
 
 type LogConfig struct {
-	Level string `yaml:"level"`
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"` // text or json
 }
 
 type AuthConfig struct {
-	SecretKey             string `yaml:"secret_key"`
-	AccessTokenExpireTime int    `yaml:"access_token_expire_minute"`
+	// HS256 secret. It signs tokens when signing_key_id is empty, otherwise it only verifies the tokens issued before
+	SecretKey string `yaml:"secret_key"`
+	// Directory of the RSA or Ed25519 keys, one <kid>.pem file per key. A file with only a public key can only verify
+	KeyDir string `yaml:"key_dir"`
+	// Kid of the key signing new tokens. To rotate, add the new key and switch to it,
+	// then remove the old key once its tokens are expired
+	SigningKeyID           string `yaml:"signing_key_id"`
+	AccessTokenExpireTime  int    `yaml:"access_token_expire_minute"`
+	RefreshTokenExpireTime int    `yaml:"refresh_token_expire_hour"`
+	// How long a token which is not revoked is cached before asking the database again
+	RevocationCacheTime int `yaml:"revocation_cache_second"`
+	// How long a password reset token can be used
+	PasswordResetExpireTime int `yaml:"password_reset_expire_minute"`
+	// Brute force protection of the sign in
+	LoginGuard LoginGuardConfig `yaml:"login_guard"`
+	// Hashing of the passwords
+	PasswordHash PasswordHashConfig `yaml:"password_hash"`
+}
+
+// Passwords are hashed with algorithm, argon2id or bcrypt. Hashes of the other algorithm are still verified,
+// they are replaced, like hashes with other parameters, when the user signs in. Zero parameters take their default value
+type PasswordHashConfig struct {
+	Algorithm         string `yaml:"algorithm"`
+	Argon2Memory      uint32 `yaml:"argon2_memory_kib"`
+	Argon2Iterations  uint32 `yaml:"argon2_iterations"`
+	Argon2Parallelism uint8  `yaml:"argon2_parallelism"`
+	BcryptCost        int    `yaml:"bcrypt_cost"`
+}
+
+// Failed sign in are tracked per username and per client IP. After a failure the next attempt is delayed,
+// the delay doubles with every failure. After max failures the username or IP is locked
+type LoginGuardConfig struct {
+	MaxUsernameFailures  int `yaml:"max_username_failures"`
+	MaxIPFailures        int `yaml:"max_ip_failures"`
+	LockoutMinute        int `yaml:"lockout_minute"`
+	FailureWindowMinute  int `yaml:"failure_window_minute"` // failures older than this are forgotten
+	BaseDelayMillisecond int `yaml:"base_delay_millisecond"`
+	MaxDelaySecond       int `yaml:"max_delay_second"`
+}
+
+// Single sign on with an OpenID Connect issuer, authorization code flow with PKCE
+type OIDCConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"` // the /auth/oidc/callback URL of this service
+	Scopes       []string `yaml:"scopes"`
+	// Claim used as username, preferred_username when empty
+	UsernameClaim string `yaml:"username_claim"`
+	// Create a user on the first sign in of an identity
+	AutoProvision     bool `yaml:"auto_provision"`
+	StateExpireMinute int  `yaml:"state_expire_minute"`
+}
+
+// Delivery of the messages to users, e.g. password reset tokens
+type NotifierConfig struct {
+	Type     string `yaml:"type"`      // log or file
+	FilePath string `yaml:"file_path"` // file of the file notifier
+}
+
+// Token bucket rate limiting per user, or per client IP when not signed in. Each route group has its own buckets,
+// a group without a rule, or with zero requests_per_minute, uses the default rule
+type RateLimitConfig struct {
+	Enabled bool                     `yaml:"enabled"`
+	Store   string                   `yaml:"store"` // memory, the buckets are kept per instance
+	Default RateLimitRule            `yaml:"default"`
+	Groups  map[string]RateLimitRule `yaml:"groups"`
+}
+
+type RateLimitRule struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"` // refill rate of the bucket
+	Burst             int `yaml:"burst"`               // size of the bucket, requests_per_minute when zero
+}
+
+// Cross-origin resource sharing. The top level policy applies to every route,
+// groups override it for the routes under a path prefix, e.g. /shared. What a group does not set comes from the top level policy
+type CORSConfig struct {
+	CORSPolicy `yaml:",inline"`
+	Groups     map[string]CORSPolicy `yaml:"groups"`
+}
+
+type CORSPolicy struct {
+	// Exact origins like https://app.example.com, subdomains like https://*.example.com, or * for any origin
+	AllowedOrigins   []string `yaml:"allowed_origins"`
+	AllowedMethods   []string `yaml:"allowed_methods"`
+	AllowedHeaders   []string `yaml:"allowed_headers"` // * allows the headers asked by the preflight
+	ExposedHeaders   []string `yaml:"exposed_headers"`
+	AllowCredentials *bool    `yaml:"allow_credentials"`
+	MaxAgeSecond     int      `yaml:"max_age_second"` // how long browsers cache a preflight
+}
+
+// Prometheus metrics. With address, /metrics is served on that admin port only, keep it off the public network.
+// Otherwise /metrics is served on the API port to the scrapers sending token as a Bearer token, it is off without a token
+type MetricsConfig struct {
+	Address string `yaml:"address"`
+	Token   string `yaml:"token"`
+}
+
+// OpenTelemetry tracing, the traceparent of the callers is followed
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"` // none, otlp, stdout or file
+	ServiceName string  `yaml:"service_name"`
+	Endpoint    string  `yaml:"endpoint"` // OTLP over HTTP, e.g. localhost:4318
+	Insecure    bool    `yaml:"insecure"` // OTLP without TLS
+	FilePath    string  `yaml:"file_path"`
+	SampleRatio float64 `yaml:"sample_ratio"` // of the traces started here, 1 when zero
+}
+
+// Caching of the GET responses. Track and playlist responses always have an ETag and a Last-Modified,
+// the clients sending them back get a 304 when nothing changed
+type HTTPCacheConfig struct {
+	// Cache-Control by route template, e.g. /music_track/get/:id. The routes not listed send none
+	CacheControl map[string]string `yaml:"cache_control"`
+}
+
+// Compression of the JSON responses with brotli or gzip
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	MinSize int  `yaml:"min_size_byte"` // smaller responses are not worth compressing
+}
+
+// Probes of /healthz and /readyz
+type HealthConfig struct {
+	CheckTimeoutSecond int `yaml:"check_timeout_second"` // of each readiness check
+	// On shutdown, /readyz fails for this long before the server stops, so the load balancers stop sending requests first
+	DrainSecond int `yaml:"drain_second"`
+}
+
+// Idempotency-Key of the creates and uploads
+type IdempotencyConfig struct {
+	Store   string `yaml:"store"`    // mongodb, shared by the instances, or memory for a single instance
+	TTLHour int    `yaml:"ttl_hour"` // how long a response is replayed, 24 when zero
+	// Largest body of a request with an Idempotency-Key, it is read in memory to be fingerprinted. 64 MiB when zero
+	MaxBodyByte int64 `yaml:"max_body_byte"`
+}
+
+// The RPC style routes replaced by /v1
+type LegacyAPIConfig struct {
+	DeprecatedAt string `yaml:"deprecated_at"` // date they are deprecated since, e.g. 2026-10-19, sent in the Deprecation header
+	SunsetAt     string `yaml:"sunset_at"`     // date they are removed, e.g. 2027-06-30, announced with the Sunset header
 }
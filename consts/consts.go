@@ -8,4 +8,34 @@ const GinDetailErrorKey = "api_additional_error"
 
 const GinResponseKey = "api_response"
 
+// Last change of the response data, set by the cacheable GET handlers with the response.
+// ResponseMiddleware then sends ETag and Last-Modified, and 304 when the copy of the client is fresh. Zero when unknown, only ETag is sent
+const GinLastModifiedKey = "api_last_modified"
+
+// Status of a successful response, 200 when not set. Set by RESTMiddleware on the /v1 routes
+const GinStatusKey = "api_status"
+
+// ID of the resource created by the handler, RESTMiddleware turns it into the Location of a 201
+const GinResourceIDKey = "api_resource_id"
+
 const GinAuthUid = "uid_auth"
+
+// Header carrying an API key, accepted by AuthMiddleware instead of a Bearer token
+const HeaderAPIKey = "X-API-Key"
+
+// Role of the authenticated user and the permissions it grants, set by AuthMiddleware. Checked by RequireRole and RequirePermission
+const GinAuthRole = "role_auth"
+const GinAuthPermissions = "permissions_auth"
+
+// The verified access token claims, set by AuthMiddleware. Used to revoke the current session on logout
+const GinAuthClaims = "claims_auth"
+
+// Header carrying the id of a request. It is generated when the client does not send one, and sent back in the response
+const HeaderRequestID = "X-Request-ID"
+
+// The id of the request, set by RequestIDMiddleware
+const GinRequestID = "request_id"
+
+// Header of the key making a create or an upload safe to retry, and the header marking a replayed response
+const HeaderIdempotencyKey = "Idempotency-Key"
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
@@ -3,11 +3,132 @@ package consts
 type NoSQLCollection string
 
 const (
-	MongoDBCollectionUsers     NoSQLCollection = "users"
-	MongoDBCollectionTracks    NoSQLCollection = "tracks"
-	MongoDBCollectionPlaylists NoSQLCollection = "playlists"
+	MongoDBCollectionUsers              NoSQLCollection = "users"
+	MongoDBCollectionTracks             NoSQLCollection = "tracks"
+	MongoDBCollectionPlaylists          NoSQLCollection = "playlists"
+	MongoDBCollectionPlaylistShares     NoSQLCollection = "playlist_shares"
+	MongoDBCollectionRefreshTokens      NoSQLCollection = "refresh_tokens"
+	MongoDBCollectionRevokedTokens      NoSQLCollection = "revoked_tokens"
+	MongoDBCollectionSessionRevocations NoSQLCollection = "session_revocations"
+	MongoDBCollectionAPIKeys            NoSQLCollection = "api_keys"
+	MongoDBCollectionOIDCStates         NoSQLCollection = "oidc_states"
+	MongoDBCollectionPasswordResets     NoSQLCollection = "password_reset_tokens"
+	MongoDBCollectionLoginAttempts      NoSQLCollection = "login_attempts"
+	MongoDBCollectionIdempotencyKeys    NoSQLCollection = "idempotency_keys"
 )
 
 func (m NoSQLCollection) String() string {
 	return string(m)
 }
+
+// PlaylistVisibility controls who can read a playlist
+//   - private: only the owner
+//   - unlisted: the owner and anyone holding a share token, not listed in search
+//   - public: every user, listed in search
+type PlaylistVisibility string
+
+const (
+	PlaylistVisibilityPrivate  PlaylistVisibility = "private"
+	PlaylistVisibilityUnlisted PlaylistVisibility = "unlisted"
+	PlaylistVisibilityPublic   PlaylistVisibility = "public"
+)
+
+func (v PlaylistVisibility) String() string {
+	return string(v)
+}
+
+// PlaylistRole is the role of a collaborator on a playlist
+//   - viewer: can read the playlist
+//   - editor: viewer + can edit the playlist information and its tracks
+//   - owner: editor + can change visibility, manage share links and collaborators, delete the playlist
+type PlaylistRole string
+
+const (
+	PlaylistRoleViewer PlaylistRole = "viewer"
+	PlaylistRoleEditor PlaylistRole = "editor"
+	PlaylistRoleOwner  PlaylistRole = "owner"
+)
+
+var playlistRoleRank = map[PlaylistRole]int{
+	PlaylistRoleViewer: 1,
+	PlaylistRoleEditor: 2,
+	PlaylistRoleOwner:  3,
+}
+
+func (r PlaylistRole) String() string {
+	return string(r)
+}
+
+// AtLeast reports whether the role grants every permission of the required role
+// An empty role has no permission
+func (r PlaylistRole) AtLeast(required PlaylistRole) bool {
+	return playlistRoleRank[r] >= playlistRoleRank[required] && playlistRoleRank[r] > 0
+}
+
+// PlaylistType tells how the tracks of a playlist are chosen
+//   - static: tracks are picked by collaborators
+//   - smart: tracks are evaluated from a rule set every time the playlist is read
+type PlaylistType string
+
+const (
+	PlaylistTypeStatic PlaylistType = "static"
+	PlaylistTypeSmart  PlaylistType = "smart"
+)
+
+func (t PlaylistType) String() string {
+	return string(t)
+}
+
+// PlaylistDeletionPolicy tells what happens to the playlists of a user deleting their account
+//   - delete: the playlists they created are deleted
+//   - transfer: the playlists they created are given to another user
+type PlaylistDeletionPolicy string
+
+const (
+	PlaylistDeletionPolicyDelete   PlaylistDeletionPolicy = "delete"
+	PlaylistDeletionPolicyTransfer PlaylistDeletionPolicy = "transfer"
+)
+
+func (p PlaylistDeletionPolicy) String() string {
+	return string(p)
+}
+
+// UserRole is the role of a user on the service, it is embedded in the access token
+type UserRole string
+
+const (
+	UserRoleAdmin    UserRole = "admin"
+	UserRoleCurator  UserRole = "curator"
+	UserRoleListener UserRole = "listener"
+)
+
+func (r UserRole) String() string {
+	return string(r)
+}
+
+// Permission is checked by RequirePermission middleware
+type Permission string
+
+const (
+	PermissionTracksRead     Permission = "tracks:read"
+	PermissionTracksWrite    Permission = "tracks:write"
+	PermissionPlaylistsRead  Permission = "playlists:read"
+	PermissionPlaylistsWrite Permission = "playlists:write"
+	PermissionUsersManage    Permission = "users:manage"
+)
+
+var rolePermissions = map[UserRole][]Permission{
+	UserRoleListener: {PermissionTracksRead, PermissionPlaylistsRead, PermissionPlaylistsWrite},
+	UserRoleCurator:  {PermissionTracksRead, PermissionTracksWrite, PermissionPlaylistsRead, PermissionPlaylistsWrite},
+	UserRoleAdmin:    {PermissionTracksRead, PermissionTracksWrite, PermissionPlaylistsRead, PermissionPlaylistsWrite, PermissionUsersManage},
+}
+
+// Permissions granted to the role, an unknown role has no permission
+func (r UserRole) Permissions() []Permission {
+	return rolePermissions[r]
+}
+
+func (r UserRole) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
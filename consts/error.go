@@ -1,37 +1,60 @@
 package consts
 
-type errorDeatil struct {
-	Code    int    `json:"code"` // Error code for the client to handle
-	Message string `json:"message"`
-}
+import "emvn/pkg/apperror"
 
-type CustomError struct {
-	errorDeatil
-	HttpStatus int `json:"http"` // HTTP status code
-}
-
-// Implement the error interface
-func (e CustomError) Error() string {
-	return e.Message
-}
-
-func (e CustomError) Detail() errorDeatil {
-	return e.errorDeatil
-}
+// CustomError is the error sent to the client, with its code and HTTP status. See apperror for the wrapping of causes
+type CustomError = apperror.Error
 
+// The codes are registered in apperror, which panics when a code is used twice
 var (
-	CodeInvalidToken       = CustomError{HttpStatus: 401, errorDeatil: errorDeatil{Code: 1001, Message: "Invalid token"}}
-	CodeTokenExpired       = CustomError{HttpStatus: 401, errorDeatil: errorDeatil{Code: 1002, Message: "Token expired"}}
-	CodeRedisKeyNotFound   = CustomError{HttpStatus: 500, errorDeatil: errorDeatil{Code: 1003, Message: "Redis key not found"}}
-	CodeUserAlreadyExist   = CustomError{HttpStatus: 400, errorDeatil: errorDeatil{Code: 1004, Message: "User already exist"}}
-	CodeWrongPassword      = CustomError{HttpStatus: 400, errorDeatil: errorDeatil{Code: 1005, Message: "Wrong password"}}
-	CodeInternalError      = CustomError{HttpStatus: 500, errorDeatil: errorDeatil{Code: 1006, Message: "Internal error"}}
-	CodeInvalidRequest     = CustomError{HttpStatus: 400, errorDeatil: errorDeatil{Code: 1007, Message: "Invalid request"}}
-	CodeTokenRequired      = CustomError{HttpStatus: 401, errorDeatil: errorDeatil{Code: 1008, Message: "Token required"}}
-	CodeUserAlreadyExists  = CustomError{HttpStatus: 400, errorDeatil: errorDeatil{Code: 1009, Message: "User already exists"}}
-	CodeStorageError       = CustomError{HttpStatus: 500, errorDeatil: errorDeatil{Code: 1010, Message: "Storage error"}}
-	CodeFileNotFound       = CustomError{HttpStatus: 404, errorDeatil: errorDeatil{Code: 1011, Message: "File not found"}}
-	CodeFileInvalid        = CustomError{HttpStatus: 400, errorDeatil: errorDeatil{Code: 1012, Message: "Invalid file"}}
-	CodeMusicTrackNotFound = CustomError{HttpStatus: 404, errorDeatil: errorDeatil{Code: 1013, Message: "Music track not found"}}
-	CodeUserNotFound       = CustomError{HttpStatus: 404, errorDeatil: errorDeatil{Code: 1014, Message: "User not found"}}
+	CodeInvalidToken         = apperror.New(401, 1001, "Invalid token")
+	CodeTokenExpired         = apperror.New(401, 1002, "Token expired")
+	CodeRedisKeyNotFound     = apperror.New(500, 1003, "Redis key not found")
+	CodeUserAlreadyExist     = apperror.New(400, 1004, "User already exist")
+	CodeWrongPassword        = apperror.New(400, 1005, "Wrong password")
+	CodeInternalError        = apperror.Internal // registered by apperror, unknown errors map to it
+	CodeInvalidRequest       = apperror.New(400, 1007, "Invalid request")
+	CodeTokenRequired        = apperror.New(401, 1008, "Token required")
+	CodeUserAlreadyExists    = apperror.New(400, 1009, "User already exists")
+	CodeStorageError         = apperror.New(500, 1010, "Storage error")
+	CodeFileNotFound         = apperror.New(404, 1011, "File not found")
+	CodeFileInvalid          = apperror.New(400, 1012, "Invalid file")
+	CodeMusicTrackNotFound   = apperror.New(404, 1013, "Music track not found")
+	CodeUserNotFound         = apperror.New(404, 1014, "User not found")
+	CodePlaylistNotFound     = apperror.New(404, 1015, "Playlist not found")
+	CodePlaylistForbidden    = apperror.New(403, 1016, "You do not have permission on this playlist")
+	CodeShareTokenInvalid    = apperror.New(404, 1017, "Share link is invalid or has been revoked")
+	CodePlaylistPrivate      = apperror.New(400, 1018, "Private playlist cannot be shared by link")
+	CodeCollaboratorNotFound = apperror.New(404, 1019, "Collaborator not found")
+	CodeCannotRemoveCreator  = apperror.New(400, 1020, "The creator of a playlist can not be removed or downgraded")
+	CodeInvalidSmartRules    = apperror.New(400, 1021, "Smart playlist requires a valid rule set")
+	CodePlaylistNotSmart     = apperror.New(400, 1022, "Playlist is not a smart playlist")
+	CodeUnsupportedFormat    = apperror.New(400, 1023, "Unsupported playlist format, use m3u8, xspf or json")
+	CodePlaylistFileInvalid  = apperror.New(400, 1024, "Can not parse playlist file")
+	CodePlaylistNotFork      = apperror.New(400, 1025, "Playlist is not a fork")
+	CodeInvalidRefreshToken  = apperror.New(401, 1026, "Invalid refresh token")
+	CodeRefreshTokenExpired  = apperror.New(401, 1027, "Refresh token expired")
+	CodeRefreshTokenReused   = apperror.New(401, 1028, "Refresh token reused, all sessions of this sign in have been revoked")
+	CodeTokenRevoked         = apperror.New(401, 1029, "Token revoked")
+	CodePermissionDenied     = apperror.New(403, 1030, "Permission denied")
+	CodeInvalidAPIKey        = apperror.New(401, 1031, "Invalid API key")
+	CodeInvalidScope         = apperror.New(400, 1032, "Invalid scope, a key can only get the permissions of its creator")
+	CodeAPIKeyNotFound       = apperror.New(404, 1033, "API key not found")
+	CodeOIDCDisabled         = apperror.New(404, 1034, "Single sign on is not enabled")
+	CodeOIDCStateInvalid     = apperror.New(400, 1035, "Invalid or expired sign in state, please sign in again")
+	CodeOIDCLoginFailed      = apperror.New(401, 1036, "Single sign on failed")
+	CodeOIDCUserNotAllowed   = apperror.New(403, 1037, "No user is linked to this identity")
+	CodeInvalidResetToken    = apperror.New(400, 1038, "Invalid or expired password reset token")
+	CodeInvalidCredentials   = apperror.New(401, 1039, "Invalid username or password")
+	CodeTooManyAttempts      = apperror.New(429, 1040, "Too many failed sign in, try again later")
+	CodeAccountLocked        = apperror.New(429, 1041, "Sign in is temporarily locked after too many failures")
+	CodeInvalidTransferUser  = apperror.New(400, 1042, "Playlists must be transferred to another existing user")
+	CodeRateLimited          = apperror.New(429, 1043, "Too many requests, slow down")
+	CodeNotReady             = apperror.New(503, 1044, "Service is not ready")
+	CodeInvalidIdempotency   = apperror.New(400, 1045, "Idempotency-Key must be 1 to 255 printable characters")
+	CodeIdempotencyReused    = apperror.New(409, 1046, "Idempotency-Key was already used with another request")
+	CodeIdempotencyPending   = apperror.New(409, 1047, "A request with this Idempotency-Key is still in progress")
+	CodeInvalidTrackLink     = apperror.New(400, 1048, "Link must be the path returned by the upload")
+	CodeIdempotencyTooLarge  = apperror.New(413, 1049, "Request body is too large to be sent with an Idempotency-Key")
+	CodePasswordTooLong      = apperror.New(400, 1050, "Password is too long for the hash algorithm, use at most 72 bytes")
 )
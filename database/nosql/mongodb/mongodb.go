@@ -4,13 +4,20 @@ import (
 	"context"
 	"emvn/config"
 	"emvn/consts"
-	"log"
+	"emvn/pkg/logger"
+	"emvn/pkg/metrics"
+	"emvn/pkg/tracing"
+	"errors"
+	"sync"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type mongoClient struct {
@@ -19,10 +26,44 @@ type mongoClient struct {
 
 var client mongoClient
 
+// A command is identified by its connection and its request id
+type commandKey struct {
+	connectionID string
+	requestID    int64
+}
+
 func InitClient(ctx context.Context) {
+	// Spans of the commands in flight, ended when the command succeeds or fails
+	var spans sync.Map
 	cmdMonitor := &event.CommandMonitor{
-		Started: func(_ context.Context, evt *event.CommandStartedEvent) {
-			log.Print(evt.Command)
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			// Only the command name and the collection, the command itself holds password hashes and tokens
+			collection, _ := evt.Command.Lookup(evt.CommandName).StringValueOK()
+			logger.FromContext(ctx).Debug("mongo", "command", evt.CommandName, "collection", collection)
+			_, span := tracing.Start(ctx, "mongo."+evt.CommandName,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.DBSystemMongoDB,
+					semconv.DBName(evt.DatabaseName),
+					semconv.DBOperation(evt.CommandName),
+					semconv.DBMongoDBCollection(collection),
+				),
+			)
+			spans.Store(commandKey{evt.ConnectionID, evt.RequestID}, span)
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			metrics.ObserveMongoCommand(evt.CommandName, evt.Duration, nil)
+			if span, ok := spans.LoadAndDelete(commandKey{evt.ConnectionID, evt.RequestID}); ok {
+				span.(trace.Span).End()
+			}
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			err := errors.New(evt.Failure)
+			metrics.ObserveMongoCommand(evt.CommandName, evt.Duration, err)
+			if span, ok := spans.LoadAndDelete(commandKey{evt.ConnectionID, evt.RequestID}); ok {
+				tracing.RecordError(span.(trace.Span), err)
+				span.(trace.Span).End()
+			}
 		},
 	}
 	uri := config.GetConfig().Database.ConnectString
@@ -166,3 +207,24 @@ func (m mongoClient) DeleteByID(ctx context.Context, collection consts.NoSQLColl
 	}
 	return nil
 }
+
+func (m mongoClient) DeleteOne(ctx context.Context, collection consts.NoSQLCollection, filter interface{}) (*mongo.DeleteResult, error) {
+	res, err := m.Client.Collection(collection.String()).DeleteOne(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (m mongoClient) DeleteMany(ctx context.Context, collection consts.NoSQLCollection, filter interface{}) (*mongo.DeleteResult, error) {
+	res, err := m.Client.Collection(collection.String()).DeleteMany(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Ping checks that the primary is reachable
+func (c mongoClient) Ping(ctx context.Context) error {
+	return c.Client.Client().Ping(ctx, readpref.Primary())
+}
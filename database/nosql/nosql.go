@@ -24,4 +24,6 @@ type NoSQLInterface interface {
 	Aggregate(ctx context.Context, collection consts.NoSQLCollection, pipeline interface{}) (*mongo.Cursor, error)
 	Count(ctx context.Context, collection consts.NoSQLCollection, filter interface{}) (int64, error)
 	DeleteByID(ctx context.Context, collection consts.NoSQLCollection, id string) error
+	DeleteOne(ctx context.Context, collection consts.NoSQLCollection, filter interface{}) (*mongo.DeleteResult, error)
+	DeleteMany(ctx context.Context, collection consts.NoSQLCollection, filter interface{}) (*mongo.DeleteResult, error)
 }
@@ -15,43 +15,51 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/signin": {
-            "post": {
-                "description": "Sign in user, return token and exp time",
-                "consumes": [
+        "/": {
+            "get": {
+                "description": "Answers like the liveness probe with the body it always had, {\"message\":\"OK\"} without the envelope, for the monitors matching it",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Legacy liveness check",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Public keys verifying the access tokens, the kid of a token header selects the key",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Auth"
                 ],
-                "summary": "Sign in user",
-                "parameters": [
-                    {
-                        "description": "Sign in user",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/auth_controller.SignInInput"
-                        }
-                    }
-                ],
+                "summary": "JSON Web Key Set",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/auth_controller.SignInOutput"
+                            "$ref": "#/definitions/keyset.JWKS"
                         }
                     }
                 }
             }
         },
-        "/auth/signup": {
-            "post": {
-                "description": "Sign up new user",
+        "/admin/user/list": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List all users with their role. Admin only",
                 "consumes": [
                     "application/json"
                 ],
@@ -59,38 +67,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
-                ],
-                "summary": "Sign up new user",
-                "parameters": [
-                    {
-                        "description": "Sign up new user",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/auth_controller.SignUpInput"
-                        }
-                    }
+                    "Admin"
                 ],
+                "summary": "List users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/auth_controller.SignUpOutput"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/user_controller.UserOutput"
+                            }
                         }
                     }
                 }
             }
         },
-        "/music_track/create": {
-            "post": {
+        "/admin/user/role/{id}": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new music track with the given information. Must upload the track file separately",
+                "description": "Set the role of a user to admin, curator or listener. It is applied on the next token refresh. Admin only",
                 "consumes": [
                     "application/json"
                 ],
@@ -98,17 +98,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Music Track"
+                    "Admin"
                 ],
-                "summary": "Create a new music track",
+                "summary": "Set the role of a user",
                 "parameters": [
                     {
-                        "description": "Music track information",
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackInput"
+                            "$ref": "#/definitions/user_controller.SetRoleInput"
                         }
                     }
                 ],
@@ -116,20 +123,20 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackOutput"
+                            "$ref": "#/definitions/user_controller.UserOutput"
                         }
                     }
                 }
             }
         },
-        "/music_track/delete/{id}": {
-            "delete": {
+        "/admin/user/unlock/{id}": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a music track by its ID",
+                "description": "Unlock the sign in of a user locked after too many failed attempts. Admin only",
                 "consumes": [
                     "application/json"
                 ],
@@ -137,13 +144,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Music Track"
+                    "Admin"
                 ],
-                "summary": "Delete a music track",
+                "summary": "Unlock the sign in of a user",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Music track ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -153,20 +160,20 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/musictrack_controller.TempOut"
+                            "$ref": "#/definitions/user_controller.UserOutput"
                         }
                     }
                 }
             }
         },
-        "/music_track/get/{id}": {
-            "get": {
+        "/api_key/create": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a music track by its ID",
+                "description": "Create a scoped API key acting on behalf of the user. The key is only returned once, send it in the X-API-Key header",
                 "consumes": [
                     "application/json"
                 ],
@@ -174,36 +181,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Music Track"
+                    "API Key"
                 ],
-                "summary": "Get a music track by ID",
+                "summary": "Create an API key",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Music track ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Name and scopes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apikey_controller.CreateAPIKeyInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.MusicTrack"
+                            "$ref": "#/definitions/apikey_usecase.CreateAPIKeyOutput"
                         }
                     }
                 }
             }
         },
-        "/music_track/search": {
+        "/api_key/list": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Search music tracks based on the provided criteria",
+                "description": "List the active API keys of the user, without the keys themselves",
                 "consumes": [
                     "application/json"
                 ],
@@ -211,56 +220,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Music Track"
-                ],
-                "summary": "Search music tracks",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Artist name",
-                        "name": "artist",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Album name",
-                        "name": "album",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Genre",
-                        "name": "genre",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Title",
-                        "name": "title",
-                        "in": "query"
-                    }
+                    "API Key"
                 ],
+                "summary": "List API keys",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.MusicTrack"
+                                "$ref": "#/definitions/model.APIKey"
                             }
                         }
                     }
                 }
             }
         },
-        "/music_track/update/{id}": {
-            "put": {
+        "/api_key/revoke/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a music track with the given information",
+                "description": "Revoke an API key of the user, it is rejected right away",
                 "consumes": [
                     "application/json"
                 ],
@@ -268,79 +251,64 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Music Track"
+                    "API Key"
                 ],
-                "summary": "Update a music track",
+                "summary": "Revoke an API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Music track ID",
+                        "description": "API key ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Music track information",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackInput"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackOutput"
+                            "$ref": "#/definitions/apikey_controller.TempOut"
                         }
                     }
                 }
             }
         },
-        "/music_track/upload": {
+        "/auth/logout": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Upload a music track file",
+                "description": "Revoke the current access token and the refresh token of this session",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Music Track"
-                ],
-                "summary": "Upload a music track",
-                "parameters": [
-                    {
-                        "type": "file",
-                        "description": "Music track file",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    }
+                    "Auth"
                 ],
+                "summary": "Log out",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/musictrack_controller.UploadTrackOutput"
+                            "$ref": "#/definitions/auth_controller.SignUpOutput"
                         }
                     }
                 }
             }
         },
-        "/playlist/create": {
+        "/auth/logout_all": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Note that all track ids must be valid",
+                "description": "Revoke every access token and refresh token of the current user, on every device",
                 "consumes": [
                     "application/json"
                 ],
@@ -348,38 +316,77 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Playlist"
+                    "Auth"
                 ],
-                "summary": "Create a new playlist",
-                "parameters": [
-                    {
-                        "description": "Playlist information",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                "summary": "Log out all sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/playlist_controller.WritePlaylistInput"
+                            "$ref": "#/definitions/auth_controller.SignUpOutput"
                         }
                     }
+                }
+            }
+        },
+        "/auth/oidc/callback": {
+            "get": {
+                "description": "Finish the single sign on, the user is created or linked on first sign in. Return access token, refresh token and their exp time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Single sign on callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                            "$ref": "#/definitions/auth_controller.SignInOutput"
                         }
                     }
                 }
             }
         },
-        "/playlist/delete/{id}": {
-            "delete": {
+        "/auth/oidc/login": {
+            "get": {
+                "description": "Redirect to the OpenID Connect issuer. The issuer redirects back to /auth/oidc/callback",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Sign in with single sign on",
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    }
+                }
+            }
+        },
+        "/auth/password/change": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a playlist by its ID",
+                "description": "Change the password of the current user, the current password is required",
                 "consumes": [
                     "application/json"
                 ],
@@ -387,36 +394,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Playlist"
+                    "Auth"
                 ],
-                "summary": "Delete a playlist by ID",
+                "summary": "Change password",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Playlist ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Current and new password",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth_controller.ChangePasswordInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/playlist_controller.TempOut"
+                            "$ref": "#/definitions/auth_controller.SignUpOutput"
                         }
                     }
                 }
             }
         },
-        "/playlist/get/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get a playlist by its ID",
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Send a single use password reset token to the user. It succeeds even when the user does not exist",
                 "consumes": [
                     "application/json"
                 ],
@@ -424,36 +428,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Playlist"
+                    "Auth"
                 ],
-                "summary": "Get a playlist by ID",
+                "summary": "Request a password reset",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Playlist ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Username",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth_controller.ForgotPasswordInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                            "$ref": "#/definitions/auth_controller.SignUpOutput"
                         }
                     }
                 }
             }
         },
-        "/playlist/search": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Search playlists based on title, description, and genre",
+        "/auth/password/reset": {
+            "post": {
+                "description": "Set a new password with a reset token. Every session of the user is revoked",
                 "consumes": [
                     "application/json"
                 ],
@@ -461,50 +462,157 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Playlist"
+                    "Auth"
                 ],
-                "summary": "Search playlists based on criteria",
+                "summary": "Reset password",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Playlist title",
-                        "name": "title",
-                        "in": "query"
-                    },
+                        "description": "Reset token and new password",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth_controller.ResetPasswordInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth_controller.SignUpOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and a new refresh token. A refresh token can only be used once, reusing it revokes the whole session",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
                     {
-                        "type": "string",
-                        "description": "Playlist description",
-                        "name": "description",
-                        "in": "query"
-                    },
+                        "description": "Refresh token",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth_controller.RefreshInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth_controller.SignInOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/signin": {
+            "post": {
+                "description": "Sign in user, return access token, refresh token and their exp time. Repeated failures are delayed then locked, see the Retry-After header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Sign in user",
+                "parameters": [
                     {
-                        "type": "string",
-                        "description": "Playlist genre",
-                        "name": "genre",
-                        "in": "query"
+                        "description": "Sign in user",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth_controller.SignInInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Playlist"
-                            }
+                            "$ref": "#/definitions/auth_controller.SignInOutput"
                         }
                     }
                 }
             }
         },
-        "/playlist/update/{id}": {
-            "put": {
+        "/auth/signup": {
+            "post": {
+                "description": "Sign up new user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Sign up new user",
+                "parameters": [
+                    {
+                        "description": "Sign up new user",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth_controller.SignUpInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth_controller.SignUpOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "The process is up and serving requests. It does not check the dependencies, restarting would not fix them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a playlist by its ID",
+                "description": "Get the account and the profile of the current user",
                 "consumes": [
                     "application/json"
                 ],
@@ -512,24 +620,80 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Playlist"
+                    "Me"
                 ],
-                "summary": "Update a playlist by ID",
+                "summary": "Get my account",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_controller.UserOutput"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete the account of the current user. The playlists they created are deleted or transferred to another user,\nthey are removed from the other playlists, the tracks they created are kept without creator and every session and API key is revoked.\nWrong passwords count as failed sign in, repeated failures are delayed then locked with Retry-After",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Me"
+                ],
+                "summary": "Delete my account",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Playlist ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
+                        "description": "Password and playlist policy",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_controller.DeleteMeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_controller.TempOut"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
                     {
-                        "description": "Playlist information",
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the display name, email and avatar of the current user. Fields which are not sent are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Me"
+                ],
+                "summary": "Update my profile",
+                "parameters": [
+                    {
+                        "description": "Profile",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/playlist_controller.WritePlaylistInput"
+                            "$ref": "#/definitions/user_controller.UpdateMeInput"
                         }
                     }
                 ],
@@ -537,64 +701,2466 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                            "$ref": "#/definitions/user_controller.UserOutput"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "auth_controller.SignInInput": {
-            "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
-            "properties": {
-                "password": {
-                    "type": "string",
-                    "maxLength": 50,
-                    "minLength": 8
-                },
-                "username": {
-                    "type": "string",
-                    "maxLength": 50,
-                    "minLength": 8
+        },
+        "/me/playlists": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the playlists the current user owns or collaborates on",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Me"
+                ],
+                "summary": "List my playlists",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Playlist"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/me/tracks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the music tracks created by the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Me"
+                ],
+                "summary": "List my tracks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.MusicTrack"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/music_track/create": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new music track with the given information. Must upload the track file separately",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Create a new music track",
+                "parameters": [
+                    {
+                        "description": "Music track information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackOutput"
+                        }
+                    },
+                    "201": {
+                        "description": "On /v1, with the Location of the track",
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/music_track/delete/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a music track by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Delete a music track",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Music track ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.TempOut"
+                        }
+                    },
+                    "204": {
+                        "description": "On /v1"
+                    }
+                }
+            }
+        },
+        "/music_track/get/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a music track by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Get a music track by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Music track ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MusicTrack"
+                        }
+                    }
+                }
+            }
+        },
+        "/music_track/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search music tracks based on the provided criteria",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Search music tracks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Artist name",
+                        "name": "artist",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Album name",
+                        "name": "album",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Genre",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title",
+                        "name": "title",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.MusicTrack"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/music_track/stream/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stream the audio file of a music track. Supports Range requests, and If-None-Match and If-Modified-Since with the ETag and Last-Modified of the previous response",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Stream the audio of a music track",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Music track ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "206": {
+                        "description": "Partial Content"
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    }
+                }
+            }
+        },
+        "/music_track/update/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a music track with the given information",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Update a music track",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Music track ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Music track information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/music_track/upload": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload a music track file",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Upload a music track",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Music track file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.UploadTrackOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/collaborator/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a user as viewer, editor or owner of a playlist. The role is updated when the user is already a collaborator. Only owners can invite",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Invite a collaborator",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Collaborator",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.AddCollaboratorInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.CollaboratorsOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/collaborator/{id}/{uid}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a collaborator from a playlist. Only owners can remove others, a collaborator can remove themself",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Remove a collaborator",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collaborator user ID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.CollaboratorsOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/create": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Note that all track ids must be valid. Smart playlist is defined by rules instead of track ids",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Create a new playlist",
+                "parameters": [
+                    {
+                        "description": "Playlist information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    },
+                    "201": {
+                        "description": "On /v1, with the Location of the playlist",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/delete/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a playlist by its ID. Only owners can delete it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Delete a playlist by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.TempOut"
+                        }
+                    },
+                    "204": {
+                        "description": "On /v1"
+                    }
+                }
+            }
+        },
+        "/playlist/fork/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Copy a playlist you can read into a new private playlist owned by you. The fork records the upstream playlist",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Fork a playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/forks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the forks of a playlist which you can see",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "List forks of a playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Playlist"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/freeze/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Evaluate the rules of a smart playlist and turn it into a static playlist with those tracks. Editors and owners can freeze it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Freeze a smart playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/get/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a playlist by its ID. Private and unlisted playlists are only readable by collaborators",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Get a playlist by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a playlist from a m3u8, xspf or json file. Entries are matched to existing tracks by id, then by title and artist, then by file name. Unmatched entries are listed in the report",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Import a playlist",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Playlist file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "m3u8, xspf or json, guessed from the file extension when empty",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title of the new playlist, default to the title in the file",
+                        "name": "title",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Genre of the new playlist",
+                        "name": "genre",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "private (default), unlisted or public",
+                        "name": "visibility",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_usecase.ImportReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search playlists based on title, description, and genre. Only public playlists and the playlists you collaborate on are returned",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Search playlists based on criteria",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Playlist description",
+                        "name": "description",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Playlist genre",
+                        "name": "genre",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Playlist"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/share/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List active share links of a playlist. Only owners can list them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "List share links",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.PlaylistShare"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a revocable share link for an unlisted or public playlist. Only owners can create it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Create a share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.ShareOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/share/{id}/{token}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke a share link of a playlist. Only owners can revoke it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Revoke a share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Share token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.TempOut"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/sync/{id}": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply the tracks added and removed on the upstream playlist since the fork or the last sync. Changes made on the fork are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Pull upstream changes into a fork",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Fork playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.SyncForkOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/update/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a playlist by its ID. Editors and owners can update it, only owners can change its visibility",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Update a playlist by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Playlist information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/playlist/{id}/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download a playlist as m3u8, xspf or json file with track metadata, duration and stream URL",
+                "produces": [
+                    "application/json",
+                    "application/xspf+xml",
+                    "audio/x-mpegurl"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Export a playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "m3u8, xspf or json (default)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Ping MongoDB and write, read and delete a file in the storage, with the status and latency of each check. Answers 503 when a check is down or during the graceful shutdown, the errors are only logged",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/shared/playlist/{token}": {
+            "get": {
+                "description": "Get a playlist by its share token. No authentication required",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Get a shared playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/playlists": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search playlists based on title, description, and genre. Only public playlists and the playlists you collaborate on are returned",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Search playlists based on criteria",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Playlist description",
+                        "name": "description",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Playlist genre",
+                        "name": "genre",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Playlist"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Note that all track ids must be valid. Smart playlist is defined by rules instead of track ids",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Create a new playlist",
+                "parameters": [
+                    {
+                        "description": "Playlist information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    },
+                    "201": {
+                        "description": "On /v1, with the Location of the playlist",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/playlists/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a playlist by its ID. Private and unlisted playlists are only readable by collaborators",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Get a playlist by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a playlist by its ID. Only owners can delete it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Delete a playlist by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.TempOut"
+                        }
+                    },
+                    "204": {
+                        "description": "On /v1"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the fields sent only, the other fields are kept. Editors and owners can patch it, only owners can change the visibility",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Playlist"
+                ],
+                "summary": "Patch a playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Playlist ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.PatchPlaylistInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tracks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search music tracks based on the provided criteria",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Search music tracks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Artist name",
+                        "name": "artist",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Album name",
+                        "name": "album",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Genre",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title",
+                        "name": "title",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.MusicTrack"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new music track with the given information. Must upload the track file separately",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Create a new music track",
+                "parameters": [
+                    {
+                        "description": "Music track information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackOutput"
+                        }
+                    },
+                    "201": {
+                        "description": "On /v1, with the Location of the track",
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tracks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a music track by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Get a music track by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Music track ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.MusicTrack"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a music track by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Delete a music track",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Music track ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.TempOut"
+                        }
+                    },
+                    "204": {
+                        "description": "On /v1"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the fields sent only, the other fields are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Patch a music track",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Music track ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.PatchMusicTrackInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/musictrack_controller.WriteMusicTrackOutput"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tracks/{id}/audio": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stream the audio file of a music track. Supports Range requests, and If-None-Match and If-Modified-Since with the ETag and Last-Modified of the previous response",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Music Track"
+                ],
+                "summary": "Stream the audio of a music track",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Music track ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "206": {
+                        "description": "Partial Content"
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apikey_controller.CreateAPIKeyInput": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/consts.Permission"
+                    }
+                }
+            }
+        },
+        "apikey_controller.TempOut": {
+            "type": "object",
+            "properties": {
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "apikey_usecase.CreateAPIKeyOutput": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "first characters of the key, to recognize it",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/consts.Permission"
+                    }
+                },
+                "user_id": {
+                    "description": "the key acts on behalf of this user",
+                    "type": "string"
+                }
+            }
+        },
+        "auth_controller.ChangePasswordInput": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string",
+                    "maxLength": 1024
+                },
+                "new_password": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "minLength": 8
+                }
+            }
+        },
+        "auth_controller.ForgotPasswordInput": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth_controller.RefreshInput": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth_controller.ResetPasswordInput": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "token"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "minLength": 8
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth_controller.SignInInput": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "minLength": 8
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 8
+                }
+            }
+        },
+        "auth_controller.SignInOutput": {
+            "type": "object",
+            "properties": {
+                "exp_time": {
+                    "type": "integer"
+                },
+                "refresh_exp_time": {
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth_controller.SignUpInput": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "minLength": 8
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 8
+                }
+            }
+        },
+        "auth_controller.SignUpOutput": {
+            "type": "object",
+            "properties": {
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "consts.Permission": {
+            "type": "string",
+            "enum": [
+                "tracks:read",
+                "tracks:write",
+                "playlists:read",
+                "playlists:write",
+                "users:manage"
+            ],
+            "x-enum-varnames": [
+                "PermissionTracksRead",
+                "PermissionTracksWrite",
+                "PermissionPlaylistsRead",
+                "PermissionPlaylistsWrite",
+                "PermissionUsersManage"
+            ]
+        },
+        "consts.PlaylistDeletionPolicy": {
+            "type": "string",
+            "enum": [
+                "delete",
+                "transfer"
+            ],
+            "x-enum-varnames": [
+                "PlaylistDeletionPolicyDelete",
+                "PlaylistDeletionPolicyTransfer"
+            ]
+        },
+        "consts.PlaylistRole": {
+            "type": "string",
+            "enum": [
+                "viewer",
+                "editor",
+                "owner"
+            ],
+            "x-enum-varnames": [
+                "PlaylistRoleViewer",
+                "PlaylistRoleEditor",
+                "PlaylistRoleOwner"
+            ]
+        },
+        "consts.PlaylistType": {
+            "type": "string",
+            "enum": [
+                "static",
+                "smart"
+            ],
+            "x-enum-varnames": [
+                "PlaylistTypeStatic",
+                "PlaylistTypeSmart"
+            ]
+        },
+        "consts.PlaylistVisibility": {
+            "type": "string",
+            "enum": [
+                "private",
+                "unlisted",
+                "public"
+            ],
+            "x-enum-varnames": [
+                "PlaylistVisibilityPrivate",
+                "PlaylistVisibilityUnlisted",
+                "PlaylistVisibilityPublic"
+            ]
+        },
+        "consts.UserRole": {
+            "type": "string",
+            "enum": [
+                "admin",
+                "curator",
+                "listener"
+            ],
+            "x-enum-varnames": [
+                "UserRoleAdmin",
+                "UserRoleCurator",
+                "UserRoleListener"
+            ]
+        },
+        "health.CheckResult": {
+            "type": "object",
+            "properties": {
+                "latency_ms": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.CheckResult"
+                    }
+                },
+                "draining": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "keyset.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "Ed25519",
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "RSA",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                }
+            }
+        },
+        "keyset.JWKS": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/keyset.JWK"
+                    }
+                }
+            }
+        },
+        "model.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "first characters of the key, to recognize it",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/consts.Permission"
+                    }
+                },
+                "user_id": {
+                    "description": "the key acts on behalf of this user",
+                    "type": "string"
+                }
+            }
+        },
+        "model.MusicTrack": {
+            "type": "object",
+            "properties": {
+                "album": {
+                    "type": "string"
+                },
+                "artist": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "User who created the track, empty for tracks created before it was recorded or whose creator deleted their account",
+                    "type": "string"
+                },
+                "duration": {
+                    "type": "integer"
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "link": {
+                    "description": "URL or local file path, get from storage",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "Last change of the track or of its file, empty for tracks not changed since it was recorded",
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Playlist": {
+            "type": "object",
+            "properties": {
+                "collaborators": {
+                    "description": "Users who co-curate the playlist. The creator is always an owner and is not stored here",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PlaylistCollaborator"
+                    }
+                },
+                "created_by": {
+                    "description": "uid of the user who created the playlist",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "entries": {
+                    "description": "Who added each track of TrackIDs. TrackIDs keeps the order of the playlist",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PlaylistEntry"
+                    }
+                },
+                "fork_count": {
+                    "type": "integer"
+                },
+                "forked_from": {
+                    "description": "Provenance of a forked playlist, nil when the playlist is not a fork",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.PlaylistFork"
+                        }
+                    ]
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "rules": {
+                    "description": "only for smart playlist",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.SmartPlaylistRules"
+                        }
+                    ]
+                },
+                "title": {
+                    "type": "string"
+                },
+                "track_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "$ref": "#/definitions/consts.PlaylistType"
+                },
+                "updated_at": {
+                    "description": "Last change of the playlist, or of its tracks when a track is deleted. Empty for playlists not changed since it was recorded",
+                    "type": "string"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/consts.PlaylistVisibility"
+                }
+            }
+        },
+        "model.PlaylistCollaborator": {
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "added_by": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/consts.PlaylistRole"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PlaylistEntry": {
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "added_by": {
+                    "type": "string"
+                },
+                "track_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PlaylistFork": {
+            "type": "object",
+            "properties": {
+                "forked_at": {
+                    "type": "string"
+                },
+                "playlist_id": {
+                    "type": "string"
+                },
+                "synced_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PlaylistShare": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "playlist_id": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.SmartPlaylistRules": {
+            "type": "object",
+            "properties": {
+                "artists": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "duration_max": {
+                    "type": "integer"
+                },
+                "duration_min": {
+                    "type": "integer"
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "sort_by": {
+                    "description": "title, artist, album, year or duration",
+                    "type": "string"
+                },
+                "sort_order": {
+                    "description": "asc or desc",
+                    "type": "string"
+                },
+                "year_from": {
+                    "type": "integer"
+                },
+                "year_to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "musictrack_controller.PatchMusicTrackInput": {
+            "type": "object",
+            "properties": {
+                "album": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "artist": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "duration": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "genre": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "link": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "title": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "year": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "musictrack_controller.TempOut": {
+            "type": "object",
+            "properties": {
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "musictrack_controller.UploadTrackOutput": {
+            "type": "object",
+            "properties": {
+                "file_path": {
+                    "type": "string"
+                }
+            }
+        },
+        "musictrack_controller.WriteMusicTrackInput": {
+            "type": "object",
+            "required": [
+                "album",
+                "artist",
+                "duration",
+                "genre",
+                "link",
+                "title",
+                "year"
+            ],
+            "properties": {
+                "album": {
+                    "type": "string"
+                },
+                "artist": {
+                    "type": "string"
+                },
+                "duration": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "musictrack_controller.WriteMusicTrackOutput": {
+            "type": "object",
+            "properties": {
+                "album": {
+                    "type": "string"
+                },
+                "artist": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "User who created the track, empty for tracks created before it was recorded or whose creator deleted their account",
+                    "type": "string"
+                },
+                "duration": {
+                    "type": "integer"
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "link": {
+                    "description": "URL or local file path, get from storage",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "Last change of the track or of its file, empty for tracks not changed since it was recorded",
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "playlist_controller.AddCollaboratorInput": {
+            "type": "object",
+            "required": [
+                "role",
+                "username"
+            ],
+            "properties": {
+                "role": {
+                    "enum": [
+                        "viewer",
+                        "editor",
+                        "owner"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.PlaylistRole"
+                        }
+                    ]
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "playlist_controller.CollaboratorsOutput": {
+            "type": "object",
+            "properties": {
+                "collaborators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PlaylistCollaborator"
+                    }
+                }
+            }
+        },
+        "playlist_controller.PatchPlaylistInput": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "genre": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "rules": {
+                    "$ref": "#/definitions/playlist_controller.SmartRulesInput"
+                },
+                "title": {
+                    "type": "string",
+                    "minLength": 1
+                },
+                "track_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "enum": [
+                        "static",
+                        "smart"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.PlaylistType"
+                        }
+                    ]
+                },
+                "visibility": {
+                    "enum": [
+                        "private",
+                        "unlisted",
+                        "public"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.PlaylistVisibility"
+                        }
+                    ]
                 }
             }
         },
-        "auth_controller.SignInOutput": {
+        "playlist_controller.ShareOutput": {
             "type": "object",
             "properties": {
-                "exp_time": {
-                    "type": "integer"
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "playlist_id": {
+                    "type": "string"
                 },
                 "token": {
                     "type": "string"
                 }
             }
         },
-        "auth_controller.SignUpInput": {
+        "playlist_controller.SmartRulesInput": {
             "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
             "properties": {
-                "password": {
+                "artists": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "duration_max": {
+                    "description": "seconds",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "duration_min": {
+                    "description": "seconds",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 0
+                },
+                "sort_by": {
                     "type": "string",
-                    "maxLength": 50,
-                    "minLength": 8
+                    "enum": [
+                        "title",
+                        "artist",
+                        "album",
+                        "year",
+                        "duration"
+                    ]
                 },
-                "username": {
+                "sort_order": {
                     "type": "string",
-                    "maxLength": 50,
-                    "minLength": 8
+                    "enum": [
+                        "asc",
+                        "desc"
+                    ]
+                },
+                "year_from": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "year_to": {
+                    "type": "integer",
+                    "minimum": 0
                 }
             }
         },
-        "auth_controller.SignUpOutput": {
+        "playlist_controller.SyncForkOutput": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "playlist": {
+                    "$ref": "#/definitions/playlist_controller.WritePlaylistOutput"
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "playlist_controller.TempOut": {
             "type": "object",
             "properties": {
                 "success": {
@@ -602,202 +3168,376 @@ const docTemplate = `{
                 }
             }
         },
-        "model.MusicTrack": {
+        "playlist_controller.WritePlaylistInput": {
             "type": "object",
+            "required": [
+                "description",
+                "genre",
+                "title"
+            ],
             "properties": {
-                "album": {
-                    "type": "string"
-                },
-                "artist": {
+                "description": {
                     "type": "string"
                 },
-                "duration": {
-                    "type": "integer"
-                },
                 "genre": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
-                },
-                "link": {
-                    "description": "URL or local file path, get from storage",
-                    "type": "string"
+                "rules": {
+                    "$ref": "#/definitions/playlist_controller.SmartRulesInput"
                 },
                 "title": {
                     "type": "string"
                 },
-                "year": {
-                    "type": "integer"
+                "track_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "description": "static (default on create) or smart. Smart playlist requires rules and ignores track_ids",
+                    "enum": [
+                        "static",
+                        "smart"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.PlaylistType"
+                        }
+                    ]
+                },
+                "visibility": {
+                    "description": "private (default on create), unlisted or public",
+                    "enum": [
+                        "private",
+                        "unlisted",
+                        "public"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.PlaylistVisibility"
+                        }
+                    ]
                 }
             }
         },
-        "model.Playlist": {
+        "playlist_controller.WritePlaylistOutput": {
             "type": "object",
             "properties": {
+                "collaborators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PlaylistCollaborator"
+                    }
+                },
                 "created_by": {
-                    "description": "uid of the user who created the playlist",
                     "type": "string"
                 },
                 "description": {
                     "type": "string"
                 },
+                "fork_count": {
+                    "type": "integer"
+                },
+                "forked_from": {
+                    "$ref": "#/definitions/model.PlaylistFork"
+                },
                 "genre": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
+                "rules": {
+                    "$ref": "#/definitions/model.SmartPlaylistRules"
+                },
                 "title": {
                     "type": "string"
                 },
-                "track_ids": {
+                "tracks": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/playlist_usecase.PlaylistTrack"
                     }
+                },
+                "type": {
+                    "$ref": "#/definitions/consts.PlaylistType"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/consts.PlaylistVisibility"
                 }
             }
         },
-        "musictrack_controller.TempOut": {
+        "playlist_usecase.ImportReport": {
             "type": "object",
             "properties": {
-                "success": {
-                    "type": "boolean"
+                "matched": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/playlist_usecase.MatchedEntry"
+                    }
+                },
+                "playlist": {
+                    "$ref": "#/definitions/playlist_usecase.PlaylistWithTracks"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "unmatched": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/playlist_usecase.UnmatchedEntry"
+                    }
                 }
             }
         },
-        "musictrack_controller.UploadTrackOutput": {
+        "playlist_usecase.MatchMethod": {
+            "type": "string",
+            "enum": [
+                "id",
+                "title_artist",
+                "file_name"
+            ],
+            "x-enum-varnames": [
+                "MatchByID",
+                "MatchByTitleArtist",
+                "MatchByFileName"
+            ]
+        },
+        "playlist_usecase.MatchedEntry": {
             "type": "object",
             "properties": {
-                "file_path": {
+                "index": {
+                    "description": "position in the imported file, starts at 0",
+                    "type": "integer"
+                },
+                "matched_by": {
+                    "$ref": "#/definitions/playlist_usecase.MatchMethod"
+                },
+                "track_id": {
                     "type": "string"
                 }
             }
         },
-        "musictrack_controller.WriteMusicTrackInput": {
+        "playlist_usecase.PlaylistTrack": {
             "type": "object",
-            "required": [
-                "album",
-                "artist",
-                "duration",
-                "genre",
-                "link",
-                "title",
-                "year"
-            ],
             "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "added_by": {
+                    "type": "string"
+                },
                 "album": {
                     "type": "string"
                 },
                 "artist": {
                     "type": "string"
                 },
+                "created_by": {
+                    "description": "User who created the track, empty for tracks created before it was recorded or whose creator deleted their account",
+                    "type": "string"
+                },
                 "duration": {
-                    "type": "integer",
-                    "minimum": 1
+                    "type": "integer"
                 },
                 "genre": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "string"
+                },
                 "link": {
+                    "description": "URL or local file path, get from storage",
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
                 },
+                "updated_at": {
+                    "description": "Last change of the track or of its file, empty for tracks not changed since it was recorded",
+                    "type": "string"
+                },
                 "year": {
-                    "type": "integer",
-                    "minimum": 1
+                    "type": "integer"
                 }
             }
         },
-        "musictrack_controller.WriteMusicTrackOutput": {
+        "playlist_usecase.PlaylistWithTracks": {
             "type": "object",
             "properties": {
-                "album": {
+                "collaborators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PlaylistCollaborator"
+                    }
+                },
+                "created_by": {
+                    "description": "uid of the user who created the playlist",
                     "type": "string"
                 },
-                "artist": {
+                "description": {
                     "type": "string"
                 },
-                "duration": {
+                "fork_count": {
                     "type": "integer"
                 },
+                "forked_from": {
+                    "$ref": "#/definitions/model.PlaylistFork"
+                },
                 "genre": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "link": {
-                    "description": "URL or local file path, get from storage",
-                    "type": "string"
+                "rules": {
+                    "$ref": "#/definitions/model.SmartPlaylistRules"
                 },
                 "title": {
                     "type": "string"
                 },
-                "year": {
-                    "type": "integer"
+                "tracks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/playlist_usecase.PlaylistTrack"
+                    }
+                },
+                "type": {
+                    "$ref": "#/definitions/consts.PlaylistType"
+                },
+                "visibility": {
+                    "$ref": "#/definitions/consts.PlaylistVisibility"
                 }
             }
         },
-        "playlist_controller.TempOut": {
+        "playlist_usecase.UnmatchedEntry": {
             "type": "object",
             "properties": {
-                "success": {
-                    "type": "boolean"
+                "entry": {
+                    "$ref": "#/definitions/playlistformat.Entry"
+                },
+                "index": {
+                    "type": "integer"
                 }
             }
         },
-        "playlist_controller.WritePlaylistInput": {
+        "playlistformat.Entry": {
             "type": "object",
-            "required": [
-                "description",
-                "genre",
-                "title"
-            ],
             "properties": {
-                "description": {
+                "album": {
                     "type": "string"
                 },
-                "genre": {
+                "artist": {
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "seconds",
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "track id in this service",
+                    "type": "string"
+                },
+                "location": {
+                    "description": "stream URL or file path",
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
+                }
+            }
+        },
+        "user_controller.DeleteMeInput": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "description": "Required unless the user signs in with single sign on only",
+                    "type": "string",
+                    "maxLength": 1024
                 },
-                "track_ids": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "playlists": {
+                    "description": "delete (default) or transfer the playlists created by the user",
+                    "enum": [
+                        "delete",
+                        "transfer"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.PlaylistDeletionPolicy"
+                        }
+                    ]
+                },
+                "transfer_to": {
+                    "type": "string"
                 }
             }
         },
-        "playlist_controller.WritePlaylistOutput": {
+        "user_controller.SetRoleInput": {
             "type": "object",
+            "required": [
+                "role"
+            ],
             "properties": {
-                "created_by": {
+                "role": {
+                    "enum": [
+                        "admin",
+                        "curator",
+                        "listener"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/consts.UserRole"
+                        }
+                    ]
+                }
+            }
+        },
+        "user_controller.TempOut": {
+            "type": "object",
+            "properties": {
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "user_controller.UpdateMeInput": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "display_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 254
+                }
+            }
+        },
+        "user_controller.UserOutput": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
                     "type": "string"
                 },
-                "description": {
+                "display_name": {
                     "type": "string"
                 },
-                "genre": {
+                "email": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "title": {
-                    "type": "string"
+                "role": {
+                    "$ref": "#/definitions/consts.UserRole"
                 },
-                "tracks": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/model.MusicTrack"
-                    }
+                "username": {
+                    "type": "string"
                 }
             }
         }
@@ -818,7 +3558,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "",
 	Schemes:          []string{},
 	Title:            "EMVN API",
-	Description:      "Phan Trần Khánh Hưng EMVN",
+	Description:      "Hung.Phan EMVN",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,120 @@
+package apikey_controller
+
+import (
+	"emvn/consts"
+	apikey_usecase "emvn/internal/usecase/api_key"
+	"emvn/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IAPIKeyController interface {
+	Create(c *gin.Context)
+	List(c *gin.Context)
+	Revoke(c *gin.Context)
+}
+
+type apiKeyController struct {
+	apiKeyUsecase apikey_usecase.IAPIKeyUsecase
+}
+
+func NewController(apiKeyUsecase apikey_usecase.IAPIKeyUsecase) IAPIKeyController {
+	return &apiKeyController{
+		apiKeyUsecase: apiKeyUsecase,
+	}
+}
+
+// Create swagger documentation
+//
+//	@Summary		Create an API key
+//	@Description	Create a scoped API key acting on behalf of the user. The key is only returned once, send it in the X-API-Key header
+//	@Tags			API Key
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		CreateAPIKeyInput	true	"Name and scopes"
+//	@Success		200		{object}	apikey_usecase.CreateAPIKeyOutput
+//	@Router			/api_key/create [post]
+func (ctrl *apiKeyController) Create(c *gin.Context) {
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	var in CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+
+	role, _ := c.Get(consts.GinAuthRole)
+	userRole, _ := role.(consts.UserRole)
+	out, err := ctrl.apiKeyUsecase.Create(c, uid, userRole, in.Name, in.Scopes)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, out)
+}
+
+// List swagger documentation
+//
+//	@Summary		List API keys
+//	@Description	List the active API keys of the user, without the keys themselves
+//	@Tags			API Key
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	[]model.APIKey
+//	@Router			/api_key/list [get]
+func (ctrl *apiKeyController) List(c *gin.Context) {
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	out, err := ctrl.apiKeyUsecase.List(c, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, out)
+}
+
+// Revoke swagger documentation
+//
+//	@Summary		Revoke an API key
+//	@Description	Revoke an API key of the user, it is rejected right away
+//	@Tags			API Key
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"API key ID"
+//	@Success		200	{object}	TempOut
+//	@Router			/api_key/revoke/{id} [delete]
+func (ctrl *apiKeyController) Revoke(c *gin.Context) {
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	id := c.Param("id")
+	if !validator.IsMongoObjectId(id) {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	err := ctrl.apiKeyUsecase.Revoke(c, id, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, gin.H{"success": true})
+}
@@ -0,0 +1,12 @@
+package apikey_controller
+
+import "emvn/consts"
+
+type CreateAPIKeyInput struct {
+	Name   string              `json:"name" binding:"required"`
+	Scopes []consts.Permission `json:"scopes" binding:"required,min=1,dive,oneof=tracks:read tracks:write playlists:read playlists:write"`
+}
+
+type TempOut struct {
+	Success bool `json:"success"`
+}
@@ -4,6 +4,11 @@ import (
 	"emvn/consts"
 	"emvn/internal/model"
 	auth_usecase "emvn/internal/usecase/auth"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,6 +16,15 @@ import (
 type IAuthController interface {
 	SignUp(c *gin.Context)
 	SignIn(c *gin.Context)
+	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
+	JWKS(c *gin.Context)
+	OIDCLogin(c *gin.Context)
+	OIDCCallback(c *gin.Context)
+	ChangePassword(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
 }
 
 type authController struct {
@@ -60,7 +74,7 @@ func (ctrl *authController) SignUp(c *gin.Context) {
 // SignIn godoc
 //
 //	@Summary		Sign in user
-//	@Description	Sign in user, return token and exp time
+//	@Description	Sign in user, return access token, refresh token and their exp time. Repeated failures are delayed then locked, see the Retry-After header
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
@@ -77,14 +91,286 @@ func (ctrl *authController) SignIn(c *gin.Context) {
 	}
 	// call usecase
 
-	out, err := ctrl.authUsecase.SignIn(c, in.Username, in.Password)
+	out, err := ctrl.authUsecase.SignIn(c, in.Username, in.Password, c.ClientIP())
 	if err != nil {
+		var throttled auth_usecase.ThrottledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			err = throttled.CustomError
+		}
 		c.Set(consts.GinErrorKey, err)
 		return
 	}
 	// response
 	c.Set(consts.GinResponseKey, SignInOutput{
-		Token: out.Token,
-		Exp:   out.Exp,
+		Token:        out.Token,
+		Exp:          out.Exp,
+		RefreshToken: out.RefreshToken,
+		RefreshExp:   out.RefreshExp,
+	})
+}
+
+// Refresh godoc
+//
+//	@Summary		Refresh access token
+//	@Description	Exchange a refresh token for a new access token and a new refresh token. A refresh token can only be used once, reusing it revokes the whole session
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		RefreshInput	true	"Refresh token"
+//	@Success		200		{object}	SignInOutput
+//	@Router			/auth/refresh [post]
+func (ctrl *authController) Refresh(c *gin.Context) {
+	// validate request
+	var in RefreshInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+	// call usecase
+
+	out, err := ctrl.authUsecase.Refresh(c, in.RefreshToken)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+	// response
+	c.Set(consts.GinResponseKey, SignInOutput{
+		Token:        out.Token,
+		Exp:          out.Exp,
+		RefreshToken: out.RefreshToken,
+		RefreshExp:   out.RefreshExp,
+	})
+}
+
+// Logout godoc
+//
+//	@Summary		Log out
+//	@Description	Revoke the current access token and the refresh token of this session
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	SignUpOutput
+//	@Router			/auth/logout [post]
+func (ctrl *authController) Logout(c *gin.Context) {
+	claims, ok := c.Get(consts.GinAuthClaims)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+	// call usecase
+
+	err := ctrl.authUsecase.Logout(c, claims.(auth_usecase.AccessToken))
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+	// response
+	c.Set(consts.GinResponseKey, SignUpOutput{
+		Success: true,
+	})
+}
+
+// LogoutAll godoc
+//
+//	@Summary		Log out all sessions
+//	@Description	Revoke every access token and refresh token of the current user, on every device
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	SignUpOutput
+//	@Router			/auth/logout_all [post]
+func (ctrl *authController) LogoutAll(c *gin.Context) {
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+	// call usecase
+
+	err := ctrl.authUsecase.LogoutAll(c, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+	// response
+	c.Set(consts.GinResponseKey, SignUpOutput{
+		Success: true,
+	})
+}
+
+// JWKS godoc
+//
+//	@Summary		JSON Web Key Set
+//	@Description	Public keys verifying the access tokens, the kid of a token header selects the key
+//	@Tags			Auth
+//	@Produce		json
+//	@Success		200	{object}	keyset.JWKS
+//	@Router			/.well-known/jwks.json [get]
+func (ctrl *authController) JWKS(c *gin.Context) {
+	// Written as is, verifiers expect the RFC 7517 format and not the response envelope
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, ctrl.authUsecase.JWKS())
+}
+
+// OIDCLogin godoc
+//
+//	@Summary		Sign in with single sign on
+//	@Description	Redirect to the OpenID Connect issuer. The issuer redirects back to /auth/oidc/callback
+//	@Tags			Auth
+//	@Success		302
+//	@Router			/auth/oidc/login [get]
+func (ctrl *authController) OIDCLogin(c *gin.Context) {
+	url, err := ctrl.authUsecase.OIDCLoginURL(c)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+	c.Redirect(http.StatusFound, url)
+}
+
+// OIDCCallback godoc
+//
+//	@Summary		Single sign on callback
+//	@Description	Finish the single sign on, the user is created or linked on first sign in. Return access token, refresh token and their exp time
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			code	query		string	true	"Authorization code"
+//	@Param			state	query		string	true	"State"
+//	@Success		200		{object}	SignInOutput
+//	@Router			/auth/oidc/callback [get]
+func (ctrl *authController) OIDCCallback(c *gin.Context) {
+	// validate request
+	var in OIDCCallbackInput
+	if err := c.ShouldBindQuery(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+	// The user denied the access or the issuer failed
+	if in.Error != "" {
+		c.Set(consts.GinErrorKey, consts.CodeOIDCLoginFailed)
+		c.Set(consts.GinDetailErrorKey, fmt.Errorf("%s: %s", in.Error, in.ErrorDescription))
+		return
+	}
+	if in.Code == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+	// call usecase
+
+	out, err := ctrl.authUsecase.OIDCCallback(c, in.Code, in.State)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+	// response
+	c.Set(consts.GinResponseKey, SignInOutput{
+		Token:        out.Token,
+		Exp:          out.Exp,
+		RefreshToken: out.RefreshToken,
+		RefreshExp:   out.RefreshExp,
+	})
+}
+
+// ChangePassword godoc
+//
+//	@Summary		Change password
+//	@Description	Change the password of the current user, the current password is required
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			body	body		ChangePasswordInput	true	"Current and new password"
+//	@Success		200		{object}	SignUpOutput
+//	@Router			/auth/password/change [post]
+func (ctrl *authController) ChangePassword(c *gin.Context) {
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+	// validate request
+	var in ChangePasswordInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+	// call usecase
+
+	err := ctrl.authUsecase.ChangePassword(c, uid, in.CurrentPassword, in.NewPassword)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+	// response
+	c.Set(consts.GinResponseKey, SignUpOutput{
+		Success: true,
+	})
+}
+
+// ForgotPassword godoc
+//
+//	@Summary		Request a password reset
+//	@Description	Send a single use password reset token to the user. It succeeds even when the user does not exist
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		ForgotPasswordInput	true	"Username"
+//	@Success		200		{object}	SignUpOutput
+//	@Router			/auth/password/forgot [post]
+func (ctrl *authController) ForgotPassword(c *gin.Context) {
+	// validate request
+	var in ForgotPasswordInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+	// call usecase
+
+	err := ctrl.authUsecase.RequestPasswordReset(c, in.Username)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+	// response
+	c.Set(consts.GinResponseKey, SignUpOutput{
+		Success: true,
+	})
+}
+
+// ResetPassword godoc
+//
+//	@Summary		Reset password
+//	@Description	Set a new password with a reset token. Every session of the user is revoked
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		ResetPasswordInput	true	"Reset token and new password"
+//	@Success		200		{object}	SignUpOutput
+//	@Router			/auth/password/reset [post]
+func (ctrl *authController) ResetPassword(c *gin.Context) {
+	// validate request
+	var in ResetPasswordInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+	// call usecase
+
+	err := ctrl.authUsecase.ResetPassword(c, in.Token, in.NewPassword)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+	// response
+	c.Set(consts.GinResponseKey, SignUpOutput{
+		Success: true,
 	})
 }
@@ -2,24 +2,55 @@ package auth_controller
 
 // Define the input and output of the controller layer here
 // Gin already support validation, so we don't need to init a validator
+// Passwords are hashed with Argon2id, which takes long passphrases. They are capped at 1024 bytes so hashing stays cheap
 
 // SignIn
 type SignInInput struct {
 	Username string `json:"username" binding:"required,min=8,max=50"`
-	Password string `json:"password" binding:"required,min=8,max=50"`
+	Password string `json:"password" binding:"required,min=8,max=1024"`
 }
 
 type SignInOutput struct {
-	Token string `json:"token"`
-	Exp   int64  `json:"exp_time"`
+	Token        string `json:"token"`
+	Exp          int64  `json:"exp_time"`
+	RefreshToken string `json:"refresh_token"`
+	RefreshExp   int64  `json:"refresh_exp_time"`
+}
+
+// Refresh
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // SignUp
 type SignUpInput struct {
 	Username string `json:"username" binding:"required,min=8,max=50"`
-	Password string `json:"password" binding:"required,min=8,max=50"`
+	Password string `json:"password" binding:"required,min=8,max=1024"`
 }
 
 type SignUpOutput struct {
 	Success bool `json:"success"`
 }
+
+// OIDC callback, sent by the issuer as query parameters
+type OIDCCallbackInput struct {
+	Code             string `form:"code"`
+	State            string `form:"state" binding:"required"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+}
+
+// Password
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required,max=1024"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=1024"`
+}
+
+type ForgotPasswordInput struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type ResetPasswordInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=1024"`
+}
@@ -0,0 +1,68 @@
+package health_controller
+
+import (
+	"emvn/consts"
+	"emvn/pkg/health"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IHealthController interface {
+	Root(c *gin.Context)
+	Liveness(c *gin.Context)
+	Readiness(c *gin.Context)
+}
+
+type healthController struct {
+	checker *health.Checker
+}
+
+func NewController(checker *health.Checker) IHealthController {
+	return &healthController{
+		checker: checker,
+	}
+}
+
+// Root swagger documentation
+//
+//	@Summary		Legacy liveness check
+//	@Description	Answers like the liveness probe with the body it always had, {"message":"OK"} without the envelope, for the monitors matching it
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200
+//	@Router			/ [get]
+func (ctrl *healthController) Root(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "OK",
+	})
+}
+
+// Liveness swagger documentation
+//
+//	@Summary		Liveness probe
+//	@Description	The process is up and serving requests. It does not check the dependencies, restarting would not fix them
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200
+//	@Router			/healthz [get]
+func (ctrl *healthController) Liveness(c *gin.Context) {
+	c.Set(consts.GinResponseKey, "OK")
+}
+
+// Readiness swagger documentation
+//
+//	@Summary		Readiness probe
+//	@Description	Ping MongoDB and write, read and delete a file in the storage, with the status and latency of each check. Answers 503 when a check is down or during the graceful shutdown, the errors are only logged
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	health.Report
+//	@Failure		503	{object}	health.Report
+//	@Router			/readyz [get]
+func (ctrl *healthController) Readiness(c *gin.Context) {
+	report := ctrl.checker.Ready(c)
+	if report.Status != health.StatusUp {
+		c.Set(consts.GinErrorKey, consts.CodeNotReady)
+	}
+	c.Set(consts.GinResponseKey, report)
+}
@@ -4,8 +4,9 @@ import (
 	"emvn/consts"
 	"emvn/internal/model"
 	musictrack_usecase "emvn/internal/usecase/music_track"
+	"emvn/pkg/logger"
+	"emvn/pkg/metrics"
 	"emvn/pkg/validator"
-	"log"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,7 +15,9 @@ type IMusicTrackController interface {
 	Create(c *gin.Context)
 	UploadTrack(c *gin.Context)
 	Get(c *gin.Context)
+	Stream(c *gin.Context)
 	Update(c *gin.Context)
+	Patch(c *gin.Context)
 	Delete(c *gin.Context)
 	Search(c *gin.Context)
 }
@@ -39,7 +42,9 @@ func NewController(musicTrackUsecase musictrack_usecase.IMusicTrackUsecase) IMus
 //	@Security		BearerAuth
 //	@Param			request	body		WriteMusicTrackInput	true	"Music track information"
 //	@Success		200		{object}	WriteMusicTrackOutput
+//	@Success		201		{object}	WriteMusicTrackOutput	"On /v1, with the Location of the track"
 //	@Router			/music_track/create [post]
+//	@Router			/v1/tracks [post]
 func (ctrl *musicTrackController) Create(c *gin.Context) {
 	// validate request
 	var in WriteMusicTrackInput
@@ -58,11 +63,14 @@ func (ctrl *musicTrackController) Create(c *gin.Context) {
 		Title:    in.Title,
 		Duration: in.Duration,
 		Link:     in.Link,
+		// Recorded so the track can be listed in the account of its creator
+		CreatedBy: c.GetString(consts.GinAuthUid),
 	})
 	if err != nil {
 		c.Set(consts.GinErrorKey, err)
 		return
 	}
+	c.Set(consts.GinResourceIDKey, newTrack.ID.Hex())
 	c.Set(consts.GinResponseKey, WriteMusicTrackOutput{
 		MusicTrack: newTrack,
 	})
@@ -84,6 +92,7 @@ func (ctrl *musicTrackController) UploadTrack(c *gin.Context) {
 		c.Set(consts.GinDetailErrorKey, err)
 		return
 	}
+	metrics.AddUploadBytes("track", file.Size)
 	filePath, err := ctrl.musicTrackUsecase.UploadTrack(c, file)
 	if err != nil {
 		c.Set(consts.GinErrorKey, err)
@@ -104,6 +113,7 @@ func (ctrl *musicTrackController) UploadTrack(c *gin.Context) {
 //	@Param			id	path		string	true	"Music track ID"
 //	@Success		200	{object}	model.MusicTrack
 //	@Router			/music_track/get/{id} [get]
+//	@Router			/v1/tracks/{id} [get]
 func (ctrl *musicTrackController) Get(c *gin.Context) {
 	id := c.Param("id")
 	ok := validator.IsMongoObjectId(id)
@@ -116,6 +126,7 @@ func (ctrl *musicTrackController) Get(c *gin.Context) {
 		c.Set(consts.GinErrorKey, err)
 		return
 	}
+	c.Set(consts.GinLastModifiedKey, track.LastModified())
 	c.Set(consts.GinResponseKey, track)
 }
 
@@ -165,6 +176,51 @@ func (ctrl *musicTrackController) Update(c *gin.Context) {
 	})
 }
 
+// PatchMusicTrack swagger documentation
+//
+//	@Summary		Patch a music track
+//	@Description	Change the fields sent only, the other fields are kept
+//	@Tags			Music Track
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string					true	"Music track ID"
+//	@Param			request	body		PatchMusicTrackInput	true	"Fields to change"
+//	@Success		200		{object}	WriteMusicTrackOutput
+//	@Router			/v1/tracks/{id} [patch]
+func (ctrl *musicTrackController) Patch(c *gin.Context) {
+	var in PatchMusicTrackInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	newTrack, err := ctrl.musicTrackUsecase.PatchMusicTrack(c, id, musictrack_usecase.MusicTrackPatch{
+		Title:    in.Title,
+		Artist:   in.Artist,
+		Album:    in.Album,
+		Genre:    in.Genre,
+		Year:     in.Year,
+		Duration: in.Duration,
+		Link:     in.Link,
+	})
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+	c.Set(consts.GinResponseKey, WriteMusicTrackOutput{
+		MusicTrack: newTrack,
+	})
+}
+
 // DeleteMusicTrack swagger documentation
 //	@Summary		Delete a music track
 //	@Description	Delete a music track by its ID
@@ -174,7 +230,9 @@ func (ctrl *musicTrackController) Update(c *gin.Context) {
 //	@Security		BearerAuth
 //	@Param			id	path		string	true	"Music track ID"
 //	@Success		200	{object}	TempOut
+//	@Success		204	"On /v1"
 //	@Router			/music_track/delete/{id} [delete]
+//	@Router			/v1/tracks/{id} [delete]
 func (ctrl *musicTrackController) Delete(c *gin.Context) {
 	id := c.Param("id")
 	ok := validator.IsMongoObjectId(id)
@@ -205,6 +263,7 @@ func (ctrl *musicTrackController) Delete(c *gin.Context) {
 //	@Param			title	query		string	false	"Title"
 //	@Success		200		{object}	[]model.MusicTrack
 //	@Router			/music_track/search [get]
+//	@Router			/v1/tracks [get]
 func (ctrl *musicTrackController) Search(c *gin.Context) {
 	// validate request
 	var in SearchMusicTrackInput
@@ -214,7 +273,7 @@ func (ctrl *musicTrackController) Search(c *gin.Context) {
 		c.Set(consts.GinDetailErrorKey, err)
 		return
 	}
-	log.Println(in)
+	logger.FromContext(c.Request.Context()).Debug("Search", "input", in)
 	// call usecase
 	tracks, err := ctrl.musicTrackUsecase.SearchMusicTrack(c, model.MusicTrack{
 		Artist: in.Artist,
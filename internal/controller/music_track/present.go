@@ -12,6 +12,17 @@ type WriteMusicTrackInput struct {
 	Link     string `json:"link" binding:"required"`
 }
 
+// Fields of a track to change, the fields not sent are kept
+type PatchMusicTrackInput struct {
+	Title    *string `json:"title" binding:"omitempty,min=1"`
+	Artist   *string `json:"artist" binding:"omitempty,min=1"`
+	Album    *string `json:"album" binding:"omitempty,min=1"`
+	Genre    *string `json:"genre" binding:"omitempty,min=1"`
+	Year     *int    `json:"year" binding:"omitempty,min=1"`
+	Duration *int    `json:"duration" binding:"omitempty,min=1"`
+	Link     *string `json:"link" binding:"omitempty,min=1"`
+}
+
 type WriteMusicTrackOutput struct {
 	model.MusicTrack
 }
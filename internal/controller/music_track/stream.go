@@ -0,0 +1,47 @@
+package musictrack_controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"emvn/consts"
+	"emvn/pkg/validator"
+	"encoding/hex"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamMusicTrack swagger documentation
+//
+//	@Summary		Stream the audio of a music track
+//	@Description	Stream the audio file of a music track. Supports Range requests, and If-None-Match and If-Modified-Since with the ETag and Last-Modified of the previous response
+//	@Tags			Music Track
+//	@Produce		octet-stream
+//	@Security		BearerAuth
+//	@Param			id	path	string	true	"Music track ID"
+//	@Success		200
+//	@Success		206
+//	@Success		304
+//	@Router			/music_track/stream/{id} [get]
+//	@Router			/v1/tracks/{id}/audio [get]
+func (ctrl *musicTrackController) Stream(c *gin.Context) {
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	file, err := ctrl.musicTrackUsecase.StreamTrack(c, id)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	// The same bytes always get the same ETag, even when the file is uploaded again
+	sum := sha256.Sum256(file.Content)
+	c.Header("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	// ServeContent answers the conditional and Range requests
+	http.ServeContent(c.Writer, c.Request, path.Base(file.Track.Link), file.Track.LastModified(), bytes.NewReader(file.Content))
+}
@@ -1,10 +1,16 @@
 package playlist_controller
 
 import (
+	"emvn/config"
 	"emvn/consts"
 	"emvn/internal/model"
 	playlist_usecase "emvn/internal/usecase/playlist"
+	"emvn/pkg/metrics"
+	"emvn/pkg/playlistformat"
 	"emvn/pkg/validator"
+	"fmt"
+	"io"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,8 +19,21 @@ type IPlaylistController interface {
 	Create(c *gin.Context)
 	Get(c *gin.Context)
 	Update(c *gin.Context)
+	Patch(c *gin.Context)
 	Delete(c *gin.Context)
 	Search(c *gin.Context)
+	CreateShare(c *gin.Context)
+	ListShares(c *gin.Context)
+	RevokeShare(c *gin.Context)
+	GetShared(c *gin.Context)
+	AddCollaborator(c *gin.Context)
+	RemoveCollaborator(c *gin.Context)
+	Freeze(c *gin.Context)
+	Export(c *gin.Context)
+	Import(c *gin.Context)
+	Fork(c *gin.Context)
+	ListForks(c *gin.Context)
+	SyncFork(c *gin.Context)
 }
 
 type playlistController struct {
@@ -30,14 +49,16 @@ func NewController(usecase playlist_usecase.IPlaylistUsecase) IPlaylistControlle
 // CreatePlaylist swagger documentation
 //
 //	@Summary		Create a new playlist
-//	@Description	Note that all track ids must be valid
+//	@Description	Note that all track ids must be valid. Smart playlist is defined by rules instead of track ids
 //	@Tags			Playlist
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			request	body		WritePlaylistInput	true	"Playlist information"
 //	@Success		200		{object}	WritePlaylistOutput
+//	@Success		201		{object}	WritePlaylistOutput	"On /v1, with the Location of the playlist"
 //	@Router			/playlist/create [post]
+//	@Router			/v1/playlists [post]
 func (ctrl *playlistController) Create(c *gin.Context) {
 	var in WritePlaylistInput
 	if err := c.ShouldBindJSON(&in); err != nil {
@@ -64,26 +85,23 @@ func (ctrl *playlistController) Create(c *gin.Context) {
 		Description: in.Description,
 		Genre:       in.Genre,
 		TrackIDs:    in.TrackIDs,
+		Visibility:  in.Visibility,
+		Type:        in.Type,
+		Rules:       in.Rules.toModel(),
 	}, uid)
 	if err != nil {
 		c.Set(consts.GinErrorKey, err)
 		return
 	}
 
-	c.Set(consts.GinResponseKey, WritePlaylistOutput{
-		Title:       newPlaylist.Title,
-		Description: newPlaylist.Description,
-		Genre:       newPlaylist.Genre,
-		CreatedBy:   newPlaylist.CreatedBy,
-		Tracks:      newPlaylist.Tracks,
-		ID:          newPlaylist.ID,
-	})
+	c.Set(consts.GinResourceIDKey, newPlaylist.ID.Hex())
+	c.Set(consts.GinResponseKey, newWritePlaylistOutput(newPlaylist))
 }
 
 // GetPlaylist swagger documentation
 //
 //	@Summary		Get a playlist by ID
-//	@Description	Get a playlist by its ID
+//	@Description	Get a playlist by its ID. Private and unlisted playlists are only readable by collaborators
 //	@Tags			Playlist
 //	@Accept			json
 //	@Produce		json
@@ -91,6 +109,7 @@ func (ctrl *playlistController) Create(c *gin.Context) {
 //	@Param			id	path		string	true	"Playlist ID"
 //	@Success		200	{object}	WritePlaylistOutput
 //	@Router			/playlist/get/{id} [get]
+//	@Router			/v1/playlists/{id} [get]
 func (ctrl *playlistController) Get(c *gin.Context) {
 	id := c.Param("id")
 	ok := validator.IsMongoObjectId(id)
@@ -99,26 +118,26 @@ func (ctrl *playlistController) Get(c *gin.Context) {
 		return
 	}
 
-	playlist, err := ctrl.usecase.Get(c, id)
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	playlist, err := ctrl.usecase.Get(c, id, uid)
 	if err != nil {
 		c.Set(consts.GinErrorKey, err)
 		return
 	}
 
-	c.Set(consts.GinResponseKey, WritePlaylistOutput{
-		Title:       playlist.Title,
-		Description: playlist.Description,
-		Genre:       playlist.Genre,
-		CreatedBy:   playlist.CreatedBy,
-		Tracks:      playlist.Tracks,
-		ID:          playlist.ID,
-	})
+	c.Set(consts.GinLastModifiedKey, playlist.LastModified)
+	c.Set(consts.GinResponseKey, newWritePlaylistOutput(playlist))
 }
 
 // UpdatePlaylist swagger documentation
 //
 //	@Summary		Update a playlist by ID
-//	@Description	Update a playlist by its ID
+//	@Description	Update a playlist by its ID. Editors and owners can update it, only owners can change its visibility
 //	@Tags			Playlist
 //	@Accept			json
 //	@Produce		json
@@ -148,39 +167,99 @@ func (ctrl *playlistController) Update(c *gin.Context) {
 		return
 	}
 
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
 	// call usecase
 	newPlaylist, err := ctrl.usecase.Update(c, id, model.Playlist{
 		Title:       in.Title,
 		Description: in.Description,
 		Genre:       in.Genre,
 		TrackIDs:    in.TrackIDs,
-	})
+		Visibility:  in.Visibility,
+		Type:        in.Type,
+		Rules:       in.Rules.toModel(),
+	}, uid)
 	if err != nil {
 		c.Set(consts.GinErrorKey, err)
 		return
 	}
 
-	c.Set(consts.GinResponseKey, WritePlaylistOutput{
-		Title:       newPlaylist.Title,
-		Description: newPlaylist.Description,
-		Genre:       newPlaylist.Genre,
-		CreatedBy:   newPlaylist.CreatedBy,
-		Tracks:      newPlaylist.Tracks,
-		ID:          newPlaylist.ID,
-	})
+	c.Set(consts.GinResponseKey, newWritePlaylistOutput(newPlaylist))
+}
+
+// PatchPlaylist swagger documentation
+//
+//	@Summary		Patch a playlist
+//	@Description	Change the fields sent only, the other fields are kept. Editors and owners can patch it, only owners can change the visibility
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string				true	"Playlist ID"
+//	@Param			request	body		PatchPlaylistInput	true	"Fields to change"
+//	@Success		200		{object}	WritePlaylistOutput
+//	@Router			/v1/playlists/{id} [patch]
+func (ctrl *playlistController) Patch(c *gin.Context) {
+	var in PatchPlaylistInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	// validate track ids
+	if in.TrackIDs != nil && !validateTrackIds(*in.TrackIDs) {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	newPlaylist, err := ctrl.usecase.Patch(c, id, playlist_usecase.PlaylistPatch{
+		Title:       in.Title,
+		Description: in.Description,
+		Genre:       in.Genre,
+		TrackIDs:    in.TrackIDs,
+		Visibility:  in.Visibility,
+		Type:        in.Type,
+		Rules:       in.Rules.toModel(),
+	}, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, newWritePlaylistOutput(newPlaylist))
 }
 
 // DeletePlaylist swagger documentation
 //
 //	@Summary		Delete a playlist by ID
-//	@Description	Delete a playlist by its ID
+//	@Description	Delete a playlist by its ID. Only owners can delete it
 //	@Tags			Playlist
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			id	path		string	true	"Playlist ID"
 //	@Success		200	{object}	TempOut
+//	@Success		204	"On /v1"
 //	@Router			/playlist/delete/{id} [delete]
+//	@Router			/v1/playlists/{id} [delete]
 func (ctrl *playlistController) Delete(c *gin.Context) {
 	id := c.Param("id")
 	ok := validator.IsMongoObjectId(id)
@@ -189,7 +268,13 @@ func (ctrl *playlistController) Delete(c *gin.Context) {
 		return
 	}
 
-	err := ctrl.usecase.Delete(c, id)
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	err := ctrl.usecase.Delete(c, id, uid)
 	if err != nil {
 		c.Set(consts.GinErrorKey, err)
 		return
@@ -201,7 +286,7 @@ func (ctrl *playlistController) Delete(c *gin.Context) {
 // SearchPlaylist swagger documentation
 //
 //	@Summary		Search playlists based on criteria
-//	@Description	Search playlists based on title, description, and genre
+//	@Description	Search playlists based on title, description, and genre. Only public playlists and the playlists you collaborate on are returned
 //	@Tags			Playlist
 //	@Accept			json
 //	@Produce		json
@@ -211,6 +296,7 @@ func (ctrl *playlistController) Delete(c *gin.Context) {
 //	@Param			genre		query		string	false	"Playlist genre"
 //	@Success		200			{object}	[]model.Playlist
 //	@Router			/playlist/search [get]
+//	@Router			/v1/playlists [get]
 func (ctrl *playlistController) Search(c *gin.Context) {
 	var in SearchPlaylistInput
 	if err := c.ShouldBindQuery(&in); err != nil {
@@ -219,12 +305,18 @@ func (ctrl *playlistController) Search(c *gin.Context) {
 		return
 	}
 
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
 	// call usecase
 	playlists, err := ctrl.usecase.Search(c, model.Playlist{
 		Title:       in.Title,
 		Description: in.Description,
 		Genre:       in.Genre,
-	})
+	}, uid)
 	if err != nil {
 		c.Set(consts.GinErrorKey, err)
 		return
@@ -233,6 +325,489 @@ func (ctrl *playlistController) Search(c *gin.Context) {
 	c.Set(consts.GinResponseKey, playlists)
 }
 
+// CreateShare swagger documentation
+//
+//	@Summary		Create a share link
+//	@Description	Create a revocable share link for an unlisted or public playlist. Only owners can create it
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Playlist ID"
+//	@Success		200	{object}	ShareOutput
+//	@Router			/playlist/share/{id} [post]
+func (ctrl *playlistController) CreateShare(c *gin.Context) {
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	share, err := ctrl.usecase.CreateShare(c, id, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, ShareOutput{
+		PlaylistShare: share,
+	})
+}
+
+// ListShares swagger documentation
+//
+//	@Summary		List share links
+//	@Description	List active share links of a playlist. Only owners can list them
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Playlist ID"
+//	@Success		200	{object}	[]model.PlaylistShare
+//	@Router			/playlist/share/{id} [get]
+func (ctrl *playlistController) ListShares(c *gin.Context) {
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	shares, err := ctrl.usecase.ListShares(c, id, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, shares)
+}
+
+// RevokeShare swagger documentation
+//
+//	@Summary		Revoke a share link
+//	@Description	Revoke a share link of a playlist. Only owners can revoke it
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Playlist ID"
+//	@Param			token	path		string	true	"Share token"
+//	@Success		200		{object}	TempOut
+//	@Router			/playlist/share/{id}/{token} [delete]
+func (ctrl *playlistController) RevokeShare(c *gin.Context) {
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	err := ctrl.usecase.RevokeShare(c, id, c.Param("token"), uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, gin.H{"success": true})
+}
+
+// GetShared swagger documentation
+//
+//	@Summary		Get a shared playlist
+//	@Description	Get a playlist by its share token. No authentication required
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	path		string	true	"Share token"
+//	@Success		200		{object}	WritePlaylistOutput
+//	@Router			/shared/playlist/{token} [get]
+func (ctrl *playlistController) GetShared(c *gin.Context) {
+	token := c.Param("token")
+	if token == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	playlist, err := ctrl.usecase.GetShared(c, token)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinLastModifiedKey, playlist.LastModified)
+	c.Set(consts.GinResponseKey, newWritePlaylistOutput(playlist))
+}
+
+// AddCollaborator swagger documentation
+//
+//	@Summary		Invite a collaborator
+//	@Description	Add a user as viewer, editor or owner of a playlist. The role is updated when the user is already a collaborator. Only owners can invite
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string					true	"Playlist ID"
+//	@Param			request	body		AddCollaboratorInput	true	"Collaborator"
+//	@Success		200		{object}	CollaboratorsOutput
+//	@Router			/playlist/collaborator/{id} [post]
+func (ctrl *playlistController) AddCollaborator(c *gin.Context) {
+	var in AddCollaboratorInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	collaborators, err := ctrl.usecase.AddCollaborator(c, id, in.Username, in.Role, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, CollaboratorsOutput{
+		Collaborators: collaborators,
+	})
+}
+
+// RemoveCollaborator swagger documentation
+//
+//	@Summary		Remove a collaborator
+//	@Description	Remove a collaborator from a playlist. Only owners can remove others, a collaborator can remove themself
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Playlist ID"
+//	@Param			uid		path		string	true	"Collaborator user ID"
+//	@Success		200		{object}	CollaboratorsOutput
+//	@Router			/playlist/collaborator/{id}/{uid} [delete]
+func (ctrl *playlistController) RemoveCollaborator(c *gin.Context) {
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	collaborators, err := ctrl.usecase.RemoveCollaborator(c, id, c.Param("uid"), uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, CollaboratorsOutput{
+		Collaborators: collaborators,
+	})
+}
+
+// FreezePlaylist swagger documentation
+//
+//	@Summary		Freeze a smart playlist
+//	@Description	Evaluate the rules of a smart playlist and turn it into a static playlist with those tracks. Editors and owners can freeze it
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Playlist ID"
+//	@Success		200	{object}	WritePlaylistOutput
+//	@Router			/playlist/freeze/{id} [post]
+func (ctrl *playlistController) Freeze(c *gin.Context) {
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	playlist, err := ctrl.usecase.Freeze(c, id, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, newWritePlaylistOutput(playlist))
+}
+
+// ExportPlaylist swagger documentation
+//
+//	@Summary		Export a playlist
+//	@Description	Download a playlist as m3u8, xspf or json file with track metadata, duration and stream URL
+//	@Tags			Playlist
+//	@Produce		json
+//	@Produce		application/xspf+xml
+//	@Produce		audio/x-mpegurl
+//	@Security		BearerAuth
+//	@Param			id		path	string	true	"Playlist ID"
+//	@Param			format	query	string	false	"m3u8, xspf or json (default)"
+//	@Success		200		{file}	file
+//	@Router			/playlist/{id}/export [get]
+func (ctrl *playlistController) Export(c *gin.Context) {
+	var in ExportPlaylistInput
+	if err := c.ShouldBindQuery(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeUnsupportedFormat)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+	if in.Format == "" {
+		in.Format = string(playlistformat.FormatJSON)
+	}
+	format, err := playlistformat.ParseFormat(in.Format)
+	if err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeUnsupportedFormat)
+		return
+	}
+
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	// The Host header is chosen by the client, only the configured URL is written in the file
+	data, err := ctrl.usecase.Export(c, id, format, config.GetConfig().Server.PublicURL, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	// The file is written directly, ResponseMiddleware only wraps data set by GinResponseKey
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="playlist-%s.%s"`, id, format))
+	c.Data(http.StatusOK, format.ContentType(), data)
+}
+
+// ImportPlaylist swagger documentation
+//
+//	@Summary		Import a playlist
+//	@Description	Create a playlist from a m3u8, xspf or json file. Entries are matched to existing tracks by id, then by title and artist, then by file name. Unmatched entries are listed in the report
+//	@Tags			Playlist
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			file		formData	file	true	"Playlist file"
+//	@Param			format		formData	string	false	"m3u8, xspf or json, guessed from the file extension when empty"
+//	@Param			title		formData	string	false	"Title of the new playlist, default to the title in the file"
+//	@Param			genre		formData	string	false	"Genre of the new playlist"
+//	@Param			visibility	formData	string	false	"private (default), unlisted or public"
+//	@Success		200			{object}	playlist_usecase.ImportReport
+//	@Router			/playlist/import [post]
+func (ctrl *playlistController) Import(c *gin.Context) {
+	var in ImportPlaylistInput
+	if err := c.ShouldBind(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeFileNotFound)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+	metrics.AddUploadBytes("playlist", file.Size)
+
+	formatName := in.Format
+	if formatName == "" {
+		formatName = file.Filename
+	}
+	format, err := playlistformat.ParseFormat(formatName)
+	if err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeUnsupportedFormat)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	fileOpen, err := file.Open()
+	if err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeFileInvalid)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+	defer fileOpen.Close()
+	data, err := io.ReadAll(fileOpen)
+	if err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeFileInvalid)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+
+	report, err := ctrl.usecase.Import(c, playlist_usecase.ImportPlaylistInput{
+		Data:       data,
+		Format:     format,
+		Title:      in.Title,
+		Genre:      in.Genre,
+		Visibility: in.Visibility,
+	}, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, report)
+}
+
+// ForkPlaylist swagger documentation
+//
+//	@Summary		Fork a playlist
+//	@Description	Copy a playlist you can read into a new private playlist owned by you. The fork records the upstream playlist
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Playlist ID"
+//	@Success		200	{object}	WritePlaylistOutput
+//	@Router			/playlist/fork/{id} [post]
+func (ctrl *playlistController) Fork(c *gin.Context) {
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	playlist, err := ctrl.usecase.Fork(c, id, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, newWritePlaylistOutput(playlist))
+}
+
+// ListForks swagger documentation
+//
+//	@Summary		List forks of a playlist
+//	@Description	List the forks of a playlist which you can see
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Playlist ID"
+//	@Success		200	{object}	[]model.Playlist
+//	@Router			/playlist/forks/{id} [get]
+func (ctrl *playlistController) ListForks(c *gin.Context) {
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	forks, err := ctrl.usecase.ListForks(c, id, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, forks)
+}
+
+// SyncFork swagger documentation
+//
+//	@Summary		Pull upstream changes into a fork
+//	@Description	Apply the tracks added and removed on the upstream playlist since the fork or the last sync. Changes made on the fork are kept
+//	@Tags			Playlist
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Fork playlist ID"
+//	@Success		200	{object}	SyncForkOutput
+//	@Router			/playlist/sync/{id} [post]
+func (ctrl *playlistController) SyncFork(c *gin.Context) {
+	id := c.Param("id")
+	ok := validator.IsMongoObjectId(id)
+	if !ok {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		return
+	}
+
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	out, err := ctrl.usecase.SyncFork(c, id, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, SyncForkOutput{
+		Playlist: newWritePlaylistOutput(out.Playlist),
+		Added:    out.Added,
+		Removed:  out.Removed,
+	})
+}
+
 func validateTrackIds(trackIds []string) bool {
 	for _, id := range trackIds {
 		if !validator.IsMongoObjectId(id) {
@@ -1,7 +1,9 @@
 package playlist_controller
 
 import (
+	"emvn/consts"
 	"emvn/internal/model"
+	playlist_usecase "emvn/internal/usecase/playlist"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -11,15 +13,89 @@ type WritePlaylistInput struct {
 	Description string   `json:"description" binding:"required"`
 	TrackIDs    []string `json:"track_ids"`
 	Genre       string   `json:"genre" binding:"required"`
+	// private (default on create), unlisted or public
+	Visibility consts.PlaylistVisibility `json:"visibility" binding:"omitempty,oneof=private unlisted public"`
+	// static (default on create) or smart. Smart playlist requires rules and ignores track_ids
+	Type  consts.PlaylistType `json:"type" binding:"omitempty,oneof=static smart"`
+	Rules *SmartRulesInput    `json:"rules"`
+}
+
+// Fields of a playlist to change, the fields not sent are kept
+type PatchPlaylistInput struct {
+	Title       *string                    `json:"title" binding:"omitempty,min=1"`
+	Description *string                    `json:"description" binding:"omitempty,min=1"`
+	TrackIDs    *[]string                  `json:"track_ids"`
+	Genre       *string                    `json:"genre" binding:"omitempty,min=1"`
+	Visibility  *consts.PlaylistVisibility `json:"visibility" binding:"omitempty,oneof=private unlisted public"`
+	Type        *consts.PlaylistType       `json:"type" binding:"omitempty,oneof=static smart"`
+	Rules       *SmartRulesInput           `json:"rules"`
+}
+
+type SmartRulesInput struct {
+	Genres      []string `json:"genres"`
+	Artists     []string `json:"artists"`
+	YearFrom    int      `json:"year_from" binding:"min=0"`
+	YearTo      int      `json:"year_to" binding:"min=0"`
+	DurationMin int      `json:"duration_min" binding:"min=0"` // seconds
+	DurationMax int      `json:"duration_max" binding:"min=0"` // seconds
+	SortBy      string   `json:"sort_by" binding:"omitempty,oneof=title artist album year duration"`
+	SortOrder   string   `json:"sort_order" binding:"omitempty,oneof=asc desc"`
+	Limit       int      `json:"limit" binding:"min=0,max=1000"`
+}
+
+func (in *SmartRulesInput) toModel() *model.SmartPlaylistRules {
+	if in == nil {
+		return nil
+	}
+	return &model.SmartPlaylistRules{
+		Genres:      in.Genres,
+		Artists:     in.Artists,
+		YearFrom:    in.YearFrom,
+		YearTo:      in.YearTo,
+		DurationMin: in.DurationMin,
+		DurationMax: in.DurationMax,
+		SortBy:      in.SortBy,
+		SortOrder:   in.SortOrder,
+		Limit:       in.Limit,
+	}
 }
 
 type WritePlaylistOutput struct {
-	ID          primitive.ObjectID `json:"id"`
-	Title       string             `json:"title"`
-	Description string             `json:"description"`
-	Genre       string             `json:"genre"`
-	CreatedBy   string             `json:"created_by"`
-	Tracks      []model.MusicTrack `json:"tracks"`
+	ID            primitive.ObjectID               `json:"id"`
+	Title         string                           `json:"title"`
+	Description   string                           `json:"description"`
+	Genre         string                           `json:"genre"`
+	Visibility    consts.PlaylistVisibility        `json:"visibility"`
+	Type          consts.PlaylistType              `json:"type"`
+	Rules         *model.SmartPlaylistRules        `json:"rules,omitempty"`
+	ForkedFrom    *model.PlaylistFork              `json:"forked_from,omitempty"`
+	ForkCount     int                              `json:"fork_count"`
+	CreatedBy     string                           `json:"created_by"`
+	Collaborators []model.PlaylistCollaborator     `json:"collaborators"`
+	Tracks        []playlist_usecase.PlaylistTrack `json:"tracks"`
+}
+
+func newWritePlaylistOutput(playlist playlist_usecase.PlaylistWithTracks) WritePlaylistOutput {
+	return WritePlaylistOutput{
+		ID:            playlist.ID,
+		Title:         playlist.Title,
+		Description:   playlist.Description,
+		Genre:         playlist.Genre,
+		Visibility:    playlist.Visibility,
+		Type:          playlist.Type,
+		Rules:         playlist.Rules,
+		ForkedFrom:    playlist.ForkedFrom,
+		ForkCount:     playlist.ForkCount,
+		CreatedBy:     playlist.CreatedBy,
+		Collaborators: playlist.Collaborators,
+		Tracks:        playlist.Tracks,
+	}
+}
+
+type SyncForkOutput struct {
+	Playlist WritePlaylistOutput `json:"playlist"`
+	Added    []string            `json:"added"`
+	Removed  []string            `json:"removed"`
 }
 
 type SearchPlaylistInput struct {
@@ -31,3 +107,28 @@ type SearchPlaylistInput struct {
 type TempOut struct {
 	Success bool `json:"success"`
 }
+
+type AddCollaboratorInput struct {
+	Username string              `json:"username" binding:"required"`
+	Role     consts.PlaylistRole `json:"role" binding:"required,oneof=viewer editor owner"`
+}
+
+type CollaboratorsOutput struct {
+	Collaborators []model.PlaylistCollaborator `json:"collaborators"`
+}
+
+type ExportPlaylistInput struct {
+	Format string `form:"format" binding:"omitempty,oneof=m3u8 xspf json"`
+}
+
+type ImportPlaylistInput struct {
+	// Format of the file, guessed from the file extension when empty
+	Format     string                    `form:"format" binding:"omitempty,oneof=m3u8 m3u xspf json"`
+	Title      string                    `form:"title"`
+	Genre      string                    `form:"genre"`
+	Visibility consts.PlaylistVisibility `form:"visibility" binding:"omitempty,oneof=private unlisted public"`
+}
+
+type ShareOutput struct {
+	model.PlaylistShare
+}
@@ -0,0 +1,194 @@
+package user_controller
+
+import (
+	"emvn/consts"
+	auth_usecase "emvn/internal/usecase/auth"
+	user_usecase "emvn/internal/usecase/user"
+	"errors"
+	"math"
+	"net/mail"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMe swagger documentation
+//
+//	@Summary		Get my account
+//	@Description	Get the account and the profile of the current user
+//	@Tags			Me
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	UserOutput
+//	@Router			/me [get]
+func (ctrl *userController) GetMe(c *gin.Context) {
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	user, err := ctrl.userUsecase.GetMe(c, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, newUserOutput(user))
+}
+
+// UpdateMe swagger documentation
+//
+//	@Summary		Update my profile
+//	@Description	Update the display name, email and avatar of the current user. Fields which are not sent are kept
+//	@Tags			Me
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		UpdateMeInput	true	"Profile"
+//	@Success		200		{object}	UserOutput
+//	@Router			/me [patch]
+func (ctrl *userController) UpdateMe(c *gin.Context) {
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	var in UpdateMeInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+	if in.Email != nil && *in.Email != "" {
+		if _, err := mail.ParseAddress(*in.Email); err != nil {
+			c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+			c.Set(consts.GinDetailErrorKey, err)
+			return
+		}
+	}
+
+	user, err := ctrl.userUsecase.GetMe(c, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+	profile := user.UserProfile
+	if in.DisplayName != nil {
+		profile.DisplayName = *in.DisplayName
+	}
+	if in.Email != nil {
+		profile.Email = *in.Email
+	}
+	if in.AvatarURL != nil {
+		profile.AvatarURL = *in.AvatarURL
+	}
+
+	user, err = ctrl.userUsecase.UpdateMe(c, uid, profile)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, newUserOutput(user))
+}
+
+// DeleteMe swagger documentation
+//
+//	@Summary		Delete my account
+//	@Description	Delete the account of the current user. The playlists they created are deleted or transferred to another user,
+//	@Description	they are removed from the other playlists, the tracks they created are kept without creator and every session and API key is revoked.
+//	@Description	Wrong passwords count as failed sign in, repeated failures are delayed then locked with Retry-After
+//	@Tags			Me
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		DeleteMeInput	true	"Password and playlist policy"
+//	@Success		200		{object}	TempOut
+//	@Router			/me [delete]
+func (ctrl *userController) DeleteMe(c *gin.Context) {
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	var in DeleteMeInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+
+	err := ctrl.userUsecase.DeleteMe(c, uid, user_usecase.DeleteMeInput{
+		Password:       in.Password,
+		PlaylistPolicy: in.Playlists,
+		TransferTo:     in.TransferTo,
+		IP:             c.ClientIP(),
+	})
+	if err != nil {
+		var throttled auth_usecase.ThrottledError
+		if errors.As(err, &throttled) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			err = throttled.CustomError
+		}
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, gin.H{"success": true})
+}
+
+// ListMyPlaylists swagger documentation
+//
+//	@Summary		List my playlists
+//	@Description	List the playlists the current user owns or collaborates on
+//	@Tags			Me
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	[]model.Playlist
+//	@Router			/me/playlists [get]
+func (ctrl *userController) ListMyPlaylists(c *gin.Context) {
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	playlists, err := ctrl.userUsecase.ListMyPlaylists(c, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, playlists)
+}
+
+// ListMyTracks swagger documentation
+//
+//	@Summary		List my tracks
+//	@Description	List the music tracks created by the current user
+//	@Tags			Me
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	[]model.MusicTrack
+//	@Router			/me/tracks [get]
+func (ctrl *userController) ListMyTracks(c *gin.Context) {
+	uid := c.GetString(consts.GinAuthUid)
+	if uid == "" {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidToken)
+		return
+	}
+
+	tracks, err := ctrl.userUsecase.ListMyTracks(c, uid)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, tracks)
+}
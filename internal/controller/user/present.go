@@ -0,0 +1,46 @@
+package user_controller
+
+import (
+	"emvn/consts"
+	"emvn/internal/model"
+)
+
+// Password hash is never returned
+type UserOutput struct {
+	ID       string          `json:"id"`
+	Username string          `json:"username"`
+	Role     consts.UserRole `json:"role"`
+	model.UserProfile
+}
+
+func newUserOutput(user model.User) UserOutput {
+	return UserOutput{
+		ID:          user.ID,
+		Username:    user.Username,
+		Role:        user.GetRole(),
+		UserProfile: user.UserProfile,
+	}
+}
+
+type SetRoleInput struct {
+	Role consts.UserRole `json:"role" binding:"required,oneof=admin curator listener"`
+}
+
+// Fields which are not sent are kept, an empty string clears the field
+type UpdateMeInput struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=100"`
+	Email       *string `json:"email" binding:"omitempty,max=254"`
+	AvatarURL   *string `json:"avatar_url" binding:"omitempty,max=2048"`
+}
+
+type DeleteMeInput struct {
+	// Required unless the user signs in with single sign on only
+	Password string `json:"password" binding:"max=1024"`
+	// delete (default) or transfer the playlists created by the user
+	Playlists  consts.PlaylistDeletionPolicy `json:"playlists" binding:"omitempty,oneof=delete transfer"`
+	TransferTo string                        `json:"transfer_to"`
+}
+
+type TempOut struct {
+	Success bool `json:"success"`
+}
@@ -0,0 +1,104 @@
+package user_controller
+
+import (
+	"emvn/consts"
+	user_usecase "emvn/internal/usecase/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IUserController interface {
+	ListUsers(c *gin.Context)
+	SetRole(c *gin.Context)
+	Unlock(c *gin.Context)
+
+	GetMe(c *gin.Context)
+	UpdateMe(c *gin.Context)
+	DeleteMe(c *gin.Context)
+	ListMyPlaylists(c *gin.Context)
+	ListMyTracks(c *gin.Context)
+}
+
+type userController struct {
+	userUsecase user_usecase.IUserUsecase
+}
+
+func NewController(userUsecase user_usecase.IUserUsecase) IUserController {
+	return &userController{
+		userUsecase: userUsecase,
+	}
+}
+
+// ListUsers swagger documentation
+//
+//	@Summary		List users
+//	@Description	List all users with their role. Admin only
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	[]UserOutput
+//	@Router			/admin/user/list [get]
+func (ctrl *userController) ListUsers(c *gin.Context) {
+	users, err := ctrl.userUsecase.ListUsers(c)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	out := make([]UserOutput, 0, len(users))
+	for _, user := range users {
+		out = append(out, newUserOutput(user))
+	}
+	c.Set(consts.GinResponseKey, out)
+}
+
+// SetRole swagger documentation
+//
+//	@Summary		Set the role of a user
+//	@Description	Set the role of a user to admin, curator or listener. It is applied on the next token refresh. Admin only
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string			true	"User ID"
+//	@Param			request	body		SetRoleInput	true	"Role"
+//	@Success		200		{object}	UserOutput
+//	@Router			/admin/user/role/{id} [put]
+func (ctrl *userController) SetRole(c *gin.Context) {
+	var in SetRoleInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.Set(consts.GinErrorKey, consts.CodeInvalidRequest)
+		c.Set(consts.GinDetailErrorKey, err)
+		return
+	}
+
+	user, err := ctrl.userUsecase.SetRole(c, c.Param("id"), in.Role)
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, newUserOutput(user))
+}
+
+// Unlock swagger documentation
+//
+//	@Summary		Unlock the sign in of a user
+//	@Description	Unlock the sign in of a user locked after too many failed attempts. Admin only
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	UserOutput
+//	@Router			/admin/user/unlock/{id} [post]
+func (ctrl *userController) Unlock(c *gin.Context) {
+	user, err := ctrl.userUsecase.Unlock(c, c.Param("id"))
+	if err != nil {
+		c.Set(consts.GinErrorKey, err)
+		return
+	}
+
+	c.Set(consts.GinResponseKey, newUserOutput(user))
+}
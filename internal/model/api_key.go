@@ -0,0 +1,22 @@
+package model
+
+import (
+	"emvn/consts"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey is a long lived credential for service to service access
+// Only its hash is stored, the plain key is shown once when it is created
+type APIKey struct {
+	ID         primitive.ObjectID  `bson:"_id" json:"id"`
+	UserID     string              `bson:"user_id" json:"user_id"` // the key acts on behalf of this user
+	Name       string              `bson:"name" json:"name"`
+	Prefix     string              `bson:"prefix" json:"prefix"` // first characters of the key, to recognize it
+	KeyHash    string              `bson:"key_hash" json:"-"`
+	Scopes     []consts.Permission `bson:"scopes" json:"scopes"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	LastUsedAt *time.Time          `bson:"last_used_at" json:"last_used_at"`
+	RevokedAt  *time.Time          `bson:"revoked_at" json:"revoked_at,omitempty"`
+}
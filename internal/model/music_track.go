@@ -1,6 +1,10 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 // Because time limit and scope of the project, we will directly store the link of the music track
 // But in my idea, we should create a cdn service
@@ -16,4 +20,16 @@ type MusicTrack struct {
 	Year     int                `bson:"year" json:"year"`
 	Duration int                `bson:"duration" json:"duration"`
 	Link     string             `bson:"link" json:"link"` // URL or local file path, get from storage
+	// User who created the track, empty for tracks created before it was recorded or whose creator deleted their account
+	CreatedBy string `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	// Last change of the track or of its file, empty for tracks not changed since it was recorded
+	UpdatedAt time.Time `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// LastModified is the last change of the track, or its creation time for tracks without updated_at
+func (t MusicTrack) LastModified() time.Time {
+	if t.UpdatedAt.IsZero() {
+		return t.ID.Timestamp()
+	}
+	return t.UpdatedAt
 }
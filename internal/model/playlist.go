@@ -1,12 +1,129 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"emvn/consts"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Playlist struct {
-	ID          primitive.ObjectID `bson:"_id" json:"id"`
-	Title       string             `bson:"title" json:"title"`
-	Description string             `bson:"description" json:"description"`
-	Genre       string             `bson:"genre" json:"genre"`
-	TrackIDs    []string           `bson:"track_ids" json:"track_ids,omitempty"`
-	CreatedBy   string             `bson:"created_by" json:"created_by"` // uid of the user who created the playlist
+	ID          primitive.ObjectID        `bson:"_id" json:"id"`
+	Title       string                    `bson:"title" json:"title"`
+	Description string                    `bson:"description" json:"description"`
+	Genre       string                    `bson:"genre" json:"genre"`
+	TrackIDs    []string                  `bson:"track_ids" json:"track_ids,omitempty"`
+	Visibility  consts.PlaylistVisibility `bson:"visibility" json:"visibility"`
+	Type        consts.PlaylistType       `bson:"type" json:"type"`
+	Rules       *SmartPlaylistRules       `bson:"rules,omitempty" json:"rules,omitempty"` // only for smart playlist
+	CreatedBy   string                    `bson:"created_by" json:"created_by"`           // uid of the user who created the playlist
+	// Users who co-curate the playlist. The creator is always an owner and is not stored here
+	Collaborators []PlaylistCollaborator `bson:"collaborators" json:"collaborators,omitempty"`
+	// Who added each track of TrackIDs. TrackIDs keeps the order of the playlist
+	Entries []PlaylistEntry `bson:"entries" json:"entries,omitempty"`
+	// Provenance of a forked playlist, nil when the playlist is not a fork
+	ForkedFrom *PlaylistFork `bson:"forked_from,omitempty" json:"forked_from,omitempty"`
+	ForkCount  int           `bson:"fork_count" json:"fork_count"`
+	// Last change of the playlist, or of its tracks when a track is deleted. Empty for playlists not changed since it was recorded
+	UpdatedAt time.Time `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// PlaylistFork records the upstream playlist of a fork
+// BaseTrackIDs is the upstream track list at the last fork or sync, it is used to find what changed upstream since then
+type PlaylistFork struct {
+	PlaylistID   string    `bson:"playlist_id" json:"playlist_id"`
+	ForkedAt     time.Time `bson:"forked_at" json:"forked_at"`
+	SyncedAt     time.Time `bson:"synced_at" json:"synced_at"`
+	BaseTrackIDs []string  `bson:"base_track_ids" json:"-"`
+}
+
+type PlaylistCollaborator struct {
+	UserID  string              `bson:"user_id" json:"user_id"`
+	Role    consts.PlaylistRole `bson:"role" json:"role"`
+	AddedBy string              `bson:"added_by" json:"added_by"`
+	AddedAt time.Time           `bson:"added_at" json:"added_at"`
+}
+
+type PlaylistEntry struct {
+	TrackID string    `bson:"track_id" json:"track_id"`
+	AddedBy string    `bson:"added_by" json:"added_by"`
+	AddedAt time.Time `bson:"added_at" json:"added_at"`
+}
+
+// LastModified is the last change of the playlist, or its creation time for playlists without updated_at
+func (p Playlist) LastModified() time.Time {
+	if p.UpdatedAt.IsZero() {
+		return p.ID.Timestamp()
+	}
+	return p.UpdatedAt
+}
+
+// Playlists created before visibility was introduced have no visibility field.
+// They were readable by every user, so we keep treating them as public
+func (p Playlist) GetVisibility() consts.PlaylistVisibility {
+	if p.Visibility == "" {
+		return consts.PlaylistVisibilityPublic
+	}
+	return p.Visibility
+}
+
+// Playlists created before smart playlists were introduced have no type, they are static
+func (p Playlist) GetType() consts.PlaylistType {
+	if p.Type == "" {
+		return consts.PlaylistTypeStatic
+	}
+	return p.Type
+}
+
+// SmartPlaylistRules is the saved rule set of a smart playlist, evaluated over MusicTrack fields
+// Empty fields are ignored. Genres and artists are matched case insensitively, a track matches when it matches any of them
+// Year and duration ranges are inclusive, duration is in seconds
+type SmartPlaylistRules struct {
+	Genres      []string `bson:"genres,omitempty" json:"genres,omitempty"`
+	Artists     []string `bson:"artists,omitempty" json:"artists,omitempty"`
+	YearFrom    int      `bson:"year_from,omitempty" json:"year_from,omitempty"`
+	YearTo      int      `bson:"year_to,omitempty" json:"year_to,omitempty"`
+	DurationMin int      `bson:"duration_min,omitempty" json:"duration_min,omitempty"`
+	DurationMax int      `bson:"duration_max,omitempty" json:"duration_max,omitempty"`
+	SortBy      string   `bson:"sort_by,omitempty" json:"sort_by,omitempty"`       // title, artist, album, year or duration
+	SortOrder   string   `bson:"sort_order,omitempty" json:"sort_order,omitempty"` // asc or desc
+	Limit       int      `bson:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// RoleOf returns the role of uid on the playlist, empty when uid is not a collaborator
+func (p Playlist) RoleOf(uid string) consts.PlaylistRole {
+	if uid == "" {
+		return ""
+	}
+	if p.CreatedBy == uid {
+		return consts.PlaylistRoleOwner
+	}
+	for _, collaborator := range p.Collaborators {
+		if collaborator.UserID == uid {
+			return collaborator.Role
+		}
+	}
+	return ""
+}
+
+// EntriesOrDefault returns the entries of the playlist
+// Playlists created before collaborators were introduced have no entries, their tracks were all added by the creator
+func (p Playlist) EntriesOrDefault() []PlaylistEntry {
+	if len(p.Entries) > 0 {
+		return p.Entries
+	}
+	entries := make([]PlaylistEntry, 0, len(p.TrackIDs))
+	for _, trackID := range p.TrackIDs {
+		entries = append(entries, PlaylistEntry{TrackID: trackID, AddedBy: p.CreatedBy})
+	}
+	return entries
+}
+
+// PlaylistShare is a revocable share link. Anyone holding the token can read the playlist, even without an account
+type PlaylistShare struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	PlaylistID string             `bson:"playlist_id" json:"playlist_id"`
+	Token      string             `bson:"token" json:"token"`
+	CreatedBy  string             `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
 }
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken is stored hashed, the plain token is only returned to the client
+// Every refresh rotates the token inside the same family. Presenting a used token again revokes the whole family
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    string             `bson:"user_id" json:"user_id"`
+	FamilyID  string             `bson:"family_id" json:"family_id"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at" json:"used_at,omitempty"`
+	RevokedAt *time.Time         `bson:"revoked_at" json:"revoked_at,omitempty"`
+}
+
+// OIDCState is created when a single sign on starts and consumed by the callback
+// It binds the callback to the sign in which started it, and keeps the PKCE verifier and the nonce out of the browser
+type OIDCState struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	StateHash    string             `bson:"state_hash" json:"-"`
+	Nonce        string             `bson:"nonce" json:"-"`
+	CodeVerifier string             `bson:"code_verifier" json:"-"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt    time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt       *time.Time         `bson:"used_at" json:"used_at,omitempty"`
+}
+
+// PasswordResetToken is stored hashed, the plain token is only sent to the user by the notifier
+type PasswordResetToken struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    string             `bson:"user_id" json:"user_id"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at" json:"used_at,omitempty"`
+}
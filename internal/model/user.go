@@ -1,7 +1,33 @@
 package model
 
+import "emvn/consts"
+
 type User struct {
-	ID       string `bson:"id" json:"id"`
-	Username string `bson:"username" json:"username"`
-	Password string `bson:"password" json:"password"`
+	ID          string          `bson:"id" json:"id"`
+	Username    string          `bson:"username" json:"username"`
+	Password    string          `bson:"password" json:"password"`
+	Role        consts.UserRole `bson:"role" json:"role"`
+	UserProfile `bson:",inline"`
+	// Identity at the OpenID Connect issuer, for users signing in with single sign on
+	OIDC *UserIdentity `bson:"oidc,omitempty" json:"oidc,omitempty"`
+}
+
+type UserIdentity struct {
+	Issuer  string `bson:"issuer" json:"issuer"`
+	Subject string `bson:"subject" json:"subject"`
+}
+
+// Users created before roles were introduced have no role, they are listeners
+func (u User) GetRole() consts.UserRole {
+	if u.Role == "" {
+		return consts.UserRoleListener
+	}
+	return u.Role
+}
+
+// UserProfile is the part of the user the user manages
+type UserProfile struct {
+	DisplayName string `bson:"display_name,omitempty" json:"display_name,omitempty"`
+	Email       string `bson:"email,omitempty" json:"email,omitempty"`
+	AvatarURL   string `bson:"avatar_url,omitempty" json:"avatar_url,omitempty"`
 }
@@ -0,0 +1,136 @@
+package apikey_repository
+
+import (
+	"context"
+	"emvn/consts"
+	"emvn/database/nosql"
+	"emvn/internal/model"
+	"emvn/pkg/logger"
+	"emvn/pkg/tracing"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IAPIKeyRepository interface {
+	Create(ctx context.Context, key model.APIKey) (model.APIKey, error)
+	// GetByHash only returns keys which are not revoked
+	GetByHash(ctx context.Context, keyHash string) (model.APIKey, error)
+	ListByUser(ctx context.Context, userID string) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id string, userID string) error
+	UpdateLastUsed(ctx context.Context, id primitive.ObjectID, lastUsedAt time.Time) error
+	RevokeAllByUser(ctx context.Context, userID string) error
+}
+
+type apiKeyRepository struct {
+	noSqlDB nosql.NoSQLInterface
+}
+
+// Singleton pattern
+var localAPIKeyRepository IAPIKeyRepository
+
+func InitAPIKeyRepository(noSqlDB nosql.NoSQLInterface) {
+	localAPIKeyRepository = &apiKeyRepository{
+		noSqlDB: noSqlDB,
+	}
+}
+
+func APIKeyRepository() IAPIKeyRepository {
+	return localAPIKeyRepository
+}
+
+func (repo *apiKeyRepository) Create(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.Create")
+	defer span.End()
+	_, err := repo.noSqlDB.InsertOne(ctx, consts.MongoDBCollectionAPIKeys, key)
+	if err != nil {
+		logger.FromContext(ctx).Error("Create", "error", err)
+		return model.APIKey{}, consts.CodeInternalError.Wrap(err)
+	}
+	return key, nil
+}
+
+func (repo *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.GetByHash")
+	defer span.End()
+	result, err := repo.noSqlDB.FindOne(ctx, consts.MongoDBCollectionAPIKeys, bson.M{"key_hash": keyHash, "revoked_at": nil})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.APIKey{}, consts.CodeInvalidAPIKey
+		}
+		logger.FromContext(ctx).Error("GetByHash", "error", err)
+		return model.APIKey{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	var key model.APIKey
+	err = result.Decode(&key)
+	if err != nil {
+		logger.FromContext(ctx).Error("GetByHash", "error", err)
+		return model.APIKey{}, consts.CodeInternalError.Wrap(err)
+	}
+	return key, nil
+}
+
+func (repo *apiKeyRepository) ListByUser(ctx context.Context, userID string) ([]model.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.ListByUser")
+	defer span.End()
+	cursor, err := repo.noSqlDB.Find(ctx, consts.MongoDBCollectionAPIKeys, bson.M{"user_id": userID, "revoked_at": nil})
+	if err != nil {
+		logger.FromContext(ctx).Error("ListByUser", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+
+	keys := []model.APIKey{}
+	err = cursor.All(ctx, &keys)
+	if err != nil {
+		logger.FromContext(ctx).Error("ListByUser", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+	return keys, nil
+}
+
+func (repo *apiKeyRepository) Revoke(ctx context.Context, id string, userID string) error {
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.Revoke")
+	defer span.End()
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return consts.CodeAPIKeyNotFound
+	}
+
+	filter := bson.M{"_id": objectID, "user_id": userID, "revoked_at": nil}
+	result, err := repo.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionAPIKeys, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		logger.FromContext(ctx).Error("Revoke", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	if result.MatchedCount == 0 {
+		return consts.CodeAPIKeyNotFound
+	}
+	return nil
+}
+
+func (repo *apiKeyRepository) UpdateLastUsed(ctx context.Context, id primitive.ObjectID, lastUsedAt time.Time) error {
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.UpdateLastUsed")
+	defer span.End()
+	_, err := repo.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionAPIKeys, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": lastUsedAt}})
+	if err != nil {
+		logger.FromContext(ctx).Error("UpdateLastUsed", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return nil
+}
+
+func (repo *apiKeyRepository) RevokeAllByUser(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.RevokeAllByUser")
+	defer span.End()
+	filter := bson.M{"user_id": userID, "revoked_at": nil}
+	_, err := repo.noSqlDB.UpdateMany(ctx, consts.MongoDBCollectionAPIKeys, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		logger.FromContext(ctx).Error("RevokeAllByUser", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return nil
+}
@@ -5,21 +5,42 @@ import (
 	"emvn/consts"
 	"emvn/database/nosql"
 	"emvn/internal/model"
+	"emvn/pkg/logger"
 	"emvn/pkg/storage"
-	"log/slog"
+	"emvn/pkg/tracing"
+	"errors"
+	"io/fs"
+	"regexp"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Max number of tracks a smart playlist can evaluate to
+const maxSmartPlaylistTracks = 1000
+
 type IMusicTrackRepository interface {
 	Create(ctx context.Context, track model.MusicTrack) (model.MusicTrack, error)
 	UploadTrack(ctx context.Context, file []byte, fileName string) (string, error)
+	GetFile(ctx context.Context, path string) ([]byte, error)
+	// IsStoredFile reports whether the path is a file of the storage, the only links a track can have
+	IsStoredFile(path string) bool
 	Get(ctx context.Context, id string) (model.MusicTrack, error)
 	Update(ctx context.Context, id string, track model.MusicTrack) (model.MusicTrack, error)
 	Delete(ctx context.Context, id string) error
 	Search(ctx context.Context, in model.MusicTrack) ([]model.MusicTrack, error)
 	GetByIDs(ctx context.Context, ids []string) ([]model.MusicTrack, error)
+	// FindByRules evaluates the rule set of a smart playlist
+	FindByRules(ctx context.Context, rules model.SmartPlaylistRules) ([]model.MusicTrack, error)
+	// FindByTitleAndArtist and FindByFileName are used to match imported playlist entries, they return CodeMusicTrackNotFound when nothing matches
+	FindByTitleAndArtist(ctx context.Context, title string, artist string) (model.MusicTrack, error)
+	FindByFileName(ctx context.Context, fileName string) (model.MusicTrack, error)
+	ListByCreator(ctx context.Context, uid string) ([]model.MusicTrack, error)
+	// AnonymizeCreator removes uid as creator of its tracks, the tracks are kept
+	AnonymizeCreator(ctx context.Context, uid string) error
 }
 
 type musicTrackRepository struct {
@@ -41,9 +62,11 @@ func MusicTrackRepository() IMusicTrackRepository {
 }
 
 func (repo *musicTrackRepository) Create(ctx context.Context, track model.MusicTrack) (model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.Create")
+	defer span.End()
 	result, err := repo.noSqlDB.InsertOne(ctx, consts.MongoDBCollectionTracks, track)
 	if err != nil {
-		slog.Error(err.Error())
+		logger.FromContext(ctx).Error("Create", "error", err)
 		return model.MusicTrack{}, consts.CodeInvalidRequest
 	}
 
@@ -51,31 +74,66 @@ func (repo *musicTrackRepository) Create(ctx context.Context, track model.MusicT
 }
 
 func (repo *musicTrackRepository) UploadTrack(ctx context.Context, file []byte, fileName string) (string, error) {
-	path, err := repo.storage.SaveFile(file, fileName)
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.UploadTrack")
+	defer span.End()
+	path, err := repo.storage.SaveFile(ctx, file, fileName)
+	if err != nil {
+		logger.FromContext(ctx).Error("UploadTrack", "error", err)
+		return "", consts.CodeStorageError.Wrap(err)
+	}
+	// A file uploaded again under the same name replaces the audio of the tracks linked to it
+	_, err = repo.noSqlDB.UpdateMany(ctx, consts.MongoDBCollectionTracks, bson.M{"link": path}, bson.M{"$set": bson.M{"updated_at": time.Now()}})
 	if err != nil {
-		slog.Error(err.Error())
-		return "", consts.CodeStorageError
+		logger.FromContext(ctx).Error("UploadTrack", "error", err)
+		return "", consts.CodeInternalError.Wrap(err)
 	}
 	return path, nil
 }
 
+// GetFile reads the audio file of a track
+func (repo *musicTrackRepository) GetFile(ctx context.Context, path string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.GetFile")
+	defer span.End()
+	// Tracks created before the links were checked may point anywhere
+	if !repo.storage.Owns(path) {
+		return nil, consts.CodeFileNotFound
+	}
+	file, err := repo.storage.GetFile(ctx, path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, consts.CodeFileNotFound
+		}
+		logger.FromContext(ctx).Error("GetFile", "error", err)
+		return nil, consts.CodeStorageError.Wrap(err)
+	}
+	return file, nil
+}
+
+func (repo *musicTrackRepository) IsStoredFile(path string) bool {
+	return repo.storage.Owns(path)
+}
+
 func (repo *musicTrackRepository) Get(ctx context.Context, id string) (model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.Get")
+	defer span.End()
 	result, err := repo.noSqlDB.FindByObjectID(ctx, consts.MongoDBCollectionTracks, id)
 	if err != nil {
-		slog.Error(err.Error())
-		return model.MusicTrack{}, consts.CodeInternalError
+		logger.FromContext(ctx).Error("Get", "error", err)
+		return model.MusicTrack{}, consts.CodeInternalError.Wrap(err)
 	}
 
 	var track model.MusicTrack
 	err = result.Decode(&track)
 	if err != nil {
-		slog.Error(err.Error())
+		logger.FromContext(ctx).Error("Get", "error", err)
 		return model.MusicTrack{}, consts.CodeMusicTrackNotFound
 	}
 	return track, nil
 }
 
 func (repo *musicTrackRepository) Update(ctx context.Context, id string, in model.MusicTrack) (model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.Update")
+	defer span.End()
 	update := bson.D{
 		{Key: "$set", Value: bson.D{
 			{Key: "duration", Value: in.Duration},
@@ -85,37 +143,50 @@ func (repo *musicTrackRepository) Update(ctx context.Context, id string, in mode
 			{Key: "album", Value: in.Album},
 			{Key: "artist", Value: in.Artist},
 			{Key: "genre", Value: in.Genre},
+			{Key: "updated_at", Value: time.Now()},
 		}},
 	}
 	_, err := repo.noSqlDB.UpdateByID(ctx, consts.MongoDBCollectionTracks, id, update)
 	if err != nil {
-		slog.Error(err.Error())
-		return model.MusicTrack{}, consts.CodeInternalError
+		logger.FromContext(ctx).Error("Update", "error", err)
+		return model.MusicTrack{}, consts.CodeInternalError.Wrap(err)
 	}
 
 	return repo.Get(ctx, id)
 }
 
 func (repo *musicTrackRepository) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.Delete")
+	defer span.End()
 	music, err := repo.Get(ctx, id)
 	if err != nil {
-		slog.Error(err.Error())
-		return consts.CodeInternalError
+		logger.FromContext(ctx).Error("Delete", "error", err)
+		return consts.CodeInternalError.Wrap(err)
 	}
 	err = repo.noSqlDB.DeleteByID(ctx, consts.MongoDBCollectionTracks, id)
 	if err != nil {
-		slog.Error(err.Error())
-		return consts.CodeInternalError
+		logger.FromContext(ctx).Error("Delete", "error", err)
+		return consts.CodeInternalError.Wrap(err)
 	}
-
-	err = repo.storage.DeleteFile(music.Link)
+	// Static playlists skip deleted tracks, so the playlists holding the track change
+	_, err = repo.noSqlDB.UpdateMany(ctx, consts.MongoDBCollectionPlaylists, bson.M{"track_ids": id}, bson.M{"$set": bson.M{"updated_at": time.Now()}})
 	if err != nil {
-		slog.Error(err.Error())
+		logger.FromContext(ctx).Error("Delete", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+
+	if repo.storage.Owns(music.Link) {
+		err = repo.storage.DeleteFile(ctx, music.Link)
+		if err != nil {
+			logger.FromContext(ctx).Error("Delete", "error", err)
+		}
 	}
 	return nil
 }
 
 func (repo *musicTrackRepository) Search(ctx context.Context, in model.MusicTrack) ([]model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.Search")
+	defer span.End()
 	fiter := bson.M{}
 	if in.Title != "" {
 		fiter["title"] = bson.M{"$regex": in.Title, "$options": "i"}
@@ -132,26 +203,28 @@ func (repo *musicTrackRepository) Search(ctx context.Context, in model.MusicTrac
 
 	result, err := repo.noSqlDB.Find(ctx, consts.MongoDBCollectionTracks, fiter)
 	if err != nil {
-		slog.Error(err.Error())
-		return nil, consts.CodeInternalError
+		logger.FromContext(ctx).Error("Search", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
 	}
 
 	var tracks []model.MusicTrack
 	err = result.All(ctx, &tracks)
 	if err != nil {
-		slog.Error(err.Error())
-		return nil, consts.CodeInternalError
+		logger.FromContext(ctx).Error("Search", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
 	}
 	return tracks, nil
 }
 
 func (repo *musicTrackRepository) GetByIDs(ctx context.Context, ids []string) ([]model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.GetByIDs")
+	defer span.End()
 	objectIDs := make([]primitive.ObjectID, 0, len(ids))
 	for _, id := range ids {
 		objectID, err := primitive.ObjectIDFromHex(id)
 		if err != nil {
-			slog.Error(err.Error())
-			return nil, consts.CodeInternalError
+			logger.FromContext(ctx).Error("GetByIDs", "error", err)
+			return nil, consts.CodeInternalError.Wrap(err)
 		}
 		objectIDs = append(objectIDs, objectID)
 	}
@@ -162,15 +235,152 @@ func (repo *musicTrackRepository) GetByIDs(ctx context.Context, ids []string) ([
 
 	result, err := repo.noSqlDB.Find(ctx, consts.MongoDBCollectionTracks, fiter)
 	if err != nil {
-		slog.Error(err.Error())
-		return nil, consts.CodeInternalError
+		logger.FromContext(ctx).Error("GetByIDs", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
 	}
 
 	var tracks []model.MusicTrack
 	err = result.All(ctx, &tracks)
 	if err != nil {
-		slog.Error(err.Error())
-		return nil, consts.CodeInternalError
+		logger.FromContext(ctx).Error("GetByIDs", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+	return tracks, nil
+}
+
+func (repo *musicTrackRepository) FindByRules(ctx context.Context, rules model.SmartPlaylistRules) ([]model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.FindByRules")
+	defer span.End()
+	fiter := bson.M{}
+	if len(rules.Genres) > 0 {
+		fiter["genre"] = bson.M{"$in": exactInsensitive(rules.Genres)}
+	}
+	if len(rules.Artists) > 0 {
+		fiter["artist"] = bson.M{"$in": exactInsensitive(rules.Artists)}
+	}
+	if year := rangeFilter(rules.YearFrom, rules.YearTo); len(year) > 0 {
+		fiter["year"] = year
+	}
+	if duration := rangeFilter(rules.DurationMin, rules.DurationMax); len(duration) > 0 {
+		fiter["duration"] = duration
+	}
+
+	limit := rules.Limit
+	if limit <= 0 || limit > maxSmartPlaylistTracks {
+		limit = maxSmartPlaylistTracks
+	}
+	opts := options.Find().SetLimit(int64(limit))
+	if rules.SortBy != "" {
+		order := 1
+		if rules.SortOrder == "desc" {
+			order = -1
+		}
+		// _id makes the order stable between reads
+		opts.SetSort(bson.D{{Key: rules.SortBy, Value: order}, {Key: "_id", Value: 1}})
+	}
+
+	result, err := repo.noSqlDB.Find(ctx, consts.MongoDBCollectionTracks, fiter, opts)
+	if err != nil {
+		logger.FromContext(ctx).Error("FindByRules", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+
+	tracks := []model.MusicTrack{}
+	err = result.All(ctx, &tracks)
+	if err != nil {
+		logger.FromContext(ctx).Error("FindByRules", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+	return tracks, nil
+}
+
+func (repo *musicTrackRepository) FindByTitleAndArtist(ctx context.Context, title string, artist string) (model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.FindByTitleAndArtist")
+	defer span.End()
+	fiter := bson.M{
+		"title":  exactInsensitive([]string{title})[0],
+		"artist": exactInsensitive([]string{artist})[0],
+	}
+	return repo.findOne(ctx, fiter)
+}
+
+func (repo *musicTrackRepository) FindByFileName(ctx context.Context, fileName string) (model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.FindByFileName")
+	defer span.End()
+	fiter := bson.M{
+		"link": primitive.Regex{Pattern: "(^|[/\\\\])" + regexp.QuoteMeta(fileName) + "$", Options: "i"},
+	}
+	return repo.findOne(ctx, fiter)
+}
+
+func (repo *musicTrackRepository) findOne(ctx context.Context, fiter bson.M) (model.MusicTrack, error) {
+	result, err := repo.noSqlDB.FindOne(ctx, consts.MongoDBCollectionTracks, fiter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.MusicTrack{}, consts.CodeMusicTrackNotFound
+		}
+		logger.FromContext(ctx).Error("findOne", "error", err)
+		return model.MusicTrack{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	var track model.MusicTrack
+	err = result.Decode(&track)
+	if err != nil {
+		logger.FromContext(ctx).Error("findOne", "error", err)
+		return model.MusicTrack{}, consts.CodeInternalError.Wrap(err)
+	}
+	return track, nil
+}
+
+// exactInsensitive builds regexes matching the whole value, ignoring case
+func exactInsensitive(values []string) bson.A {
+	regexes := bson.A{}
+	for _, value := range values {
+		regexes = append(regexes, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(value) + "$", Options: "i"})
+	}
+	return regexes
+}
+
+// rangeFilter builds an inclusive range, zero bound is ignored
+func rangeFilter(from, to int) bson.M {
+	filter := bson.M{}
+	if from > 0 {
+		filter["$gte"] = from
+	}
+	if to > 0 {
+		filter["$lte"] = to
+	}
+	return filter
+}
+
+func (repo *musicTrackRepository) ListByCreator(ctx context.Context, uid string) ([]model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.ListByCreator")
+	defer span.End()
+	cursor, err := repo.noSqlDB.Find(ctx, consts.MongoDBCollectionTracks, bson.M{"created_by": uid})
+	if err != nil {
+		logger.FromContext(ctx).Error("ListByCreator", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+
+	tracks := []model.MusicTrack{}
+	err = cursor.All(ctx, &tracks)
+	if err != nil {
+		logger.FromContext(ctx).Error("ListByCreator", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
 	}
 	return tracks, nil
 }
+
+func (repo *musicTrackRepository) AnonymizeCreator(ctx context.Context, uid string) error {
+	ctx, span := tracing.Start(ctx, "musicTrackRepository.AnonymizeCreator")
+	defer span.End()
+	_, err := repo.noSqlDB.UpdateMany(ctx, consts.MongoDBCollectionTracks, bson.M{"created_by": uid}, bson.M{
+		"$unset": bson.M{"created_by": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error("AnonymizeCreator", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return nil
+}
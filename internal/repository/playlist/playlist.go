@@ -5,18 +5,40 @@ import (
 	"emvn/consts"
 	"emvn/database/nosql"
 	"emvn/internal/model"
-	"log/slog"
+	"emvn/pkg/logger"
+	"emvn/pkg/tracing"
+	"errors"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type IPlaylistRepository interface {
 	Create(ctx context.Context, playlist model.Playlist) (model.Playlist, error)
 	Get(ctx context.Context, id string) (model.Playlist, error)
 	Update(ctx context.Context, id string, playlist model.Playlist) (model.Playlist, error)
+	// Delete removes the playlist with its share links, a token never resolves to a deleted playlist
 	Delete(ctx context.Context, id string) error
-	Search(ctx context.Context, in model.Playlist) ([]model.Playlist, error)
+	// Search only returns public playlists and the playlists viewerUID owns or collaborates on
+	Search(ctx context.Context, in model.Playlist, viewerUID string) ([]model.Playlist, error)
+	UpdateCollaborators(ctx context.Context, id string, collaborators []model.PlaylistCollaborator) (model.Playlist, error)
+	UpdateForkedFrom(ctx context.Context, id string, fork model.PlaylistFork) (model.Playlist, error)
+	IncrementForkCount(ctx context.Context, id string, delta int) error
+	// ListForks returns the forks of a playlist which viewerUID can see
+	ListForks(ctx context.Context, id string, viewerUID string) ([]model.Playlist, error)
+	// ListByUser returns the playlists uid owns or collaborates on
+	ListByUser(ctx context.Context, uid string) ([]model.Playlist, error)
+	// TransferOwnership makes newOwner the creator of the playlist, newOwner stops being a collaborator of it
+	TransferOwnership(ctx context.Context, id string, newOwner string) error
+	// RemoveCollaboratorFromAll removes uid from the collaborators of every playlist
+	RemoveCollaboratorFromAll(ctx context.Context, uid string) error
+
+	CreateShare(ctx context.Context, share model.PlaylistShare) (model.PlaylistShare, error)
+	GetShareByToken(ctx context.Context, token string) (model.PlaylistShare, error)
+	ListShares(ctx context.Context, playlistID string) ([]model.PlaylistShare, error)
+	DeleteShare(ctx context.Context, playlistID string, token string) error
 }
 
 type playlistRepository struct {
@@ -38,10 +60,12 @@ func PlaylistRepository() IPlaylistRepository {
 
 // Create a new playlist
 func (repo *playlistRepository) Create(ctx context.Context, playlist model.Playlist) (model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.Create")
+	defer span.End()
 	result, err := repo.noSqlDB.InsertOne(ctx, consts.MongoDBCollectionPlaylists, playlist)
 	if err != nil {
-		slog.Error(err.Error())
-		return model.Playlist{}, consts.CodeInternalError
+		logger.FromContext(ctx).Error("Create", "error", err)
+		return model.Playlist{}, consts.CodeInternalError.Wrap(err)
 	}
 
 	return repo.Get(ctx, result.InsertedID.(primitive.ObjectID).Hex())
@@ -49,17 +73,22 @@ func (repo *playlistRepository) Create(ctx context.Context, playlist model.Playl
 
 // Get a playlist by ID
 func (repo *playlistRepository) Get(ctx context.Context, id string) (model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.Get")
+	defer span.End()
 	result, err := repo.noSqlDB.FindByObjectID(ctx, consts.MongoDBCollectionPlaylists, id)
 	if err != nil {
-		slog.Error(err.Error())
-		return model.Playlist{}, consts.CodeInternalError
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.Playlist{}, consts.CodePlaylistNotFound
+		}
+		logger.FromContext(ctx).Error("Get", "error", err)
+		return model.Playlist{}, consts.CodeInternalError.Wrap(err)
 	}
 
 	var playlist model.Playlist
 	err = result.Decode(&playlist)
 	if err != nil {
-		slog.Error(err.Error())
-		return model.Playlist{}, consts.CodeInternalError
+		logger.FromContext(ctx).Error("Get", "error", err)
+		return model.Playlist{}, consts.CodeInternalError.Wrap(err)
 	}
 
 	return playlist, nil
@@ -67,31 +96,108 @@ func (repo *playlistRepository) Get(ctx context.Context, id string) (model.Playl
 
 // Update a playlist by ID
 func (repo *playlistRepository) Update(ctx context.Context, id string, playlist model.Playlist) (model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.Update")
+	defer span.End()
 	update := bson.D{
 		{Key: "$set", Value: bson.D{
 			{Key: "title", Value: playlist.Title},
 			{Key: "genre", Value: playlist.Genre},
 			{Key: "description", Value: playlist.Description},
 			{Key: "track_ids", Value: playlist.TrackIDs},
+			{Key: "visibility", Value: playlist.Visibility},
+			{Key: "entries", Value: playlist.Entries},
+			{Key: "type", Value: playlist.Type},
+			{Key: "rules", Value: playlist.Rules},
+			{Key: "updated_at", Value: time.Now()},
+		}},
+	}
+
+	_, err := repo.noSqlDB.UpdateByID(ctx, consts.MongoDBCollectionPlaylists, id, update)
+	if err != nil {
+		logger.FromContext(ctx).Error("Update", "error", err)
+		return model.Playlist{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	return repo.Get(ctx, id)
+}
+
+// Replace the collaborators of a playlist
+func (repo *playlistRepository) UpdateCollaborators(ctx context.Context, id string, collaborators []model.PlaylistCollaborator) (model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.UpdateCollaborators")
+	defer span.End()
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "collaborators", Value: collaborators},
+			{Key: "updated_at", Value: time.Now()},
 		}},
 	}
 
 	_, err := repo.noSqlDB.UpdateByID(ctx, consts.MongoDBCollectionPlaylists, id, update)
 	if err != nil {
-		slog.Error(err.Error())
-		return model.Playlist{}, consts.CodeInternalError
+		logger.FromContext(ctx).Error("UpdateCollaborators", "error", err)
+		return model.Playlist{}, consts.CodeInternalError.Wrap(err)
 	}
 
 	return repo.Get(ctx, id)
 }
 
-// Delete a playlist by ID
+// Update the provenance of a fork
+func (repo *playlistRepository) UpdateForkedFrom(ctx context.Context, id string, fork model.PlaylistFork) (model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.UpdateForkedFrom")
+	defer span.End()
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "forked_from", Value: fork},
+			{Key: "updated_at", Value: time.Now()},
+		}},
+	}
+
+	_, err := repo.noSqlDB.UpdateByID(ctx, consts.MongoDBCollectionPlaylists, id, update)
+	if err != nil {
+		logger.FromContext(ctx).Error("UpdateForkedFrom", "error", err)
+		return model.Playlist{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	return repo.Get(ctx, id)
+}
+
+// Increment (or decrement with negative delta) the fork count of a playlist
+func (repo *playlistRepository) IncrementForkCount(ctx context.Context, id string, delta int) error {
+	ctx, span := tracing.Start(ctx, "playlistRepository.IncrementForkCount")
+	defer span.End()
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{
+			{Key: "fork_count", Value: delta},
+		}},
+		{Key: "$set", Value: bson.D{
+			{Key: "updated_at", Value: time.Now()},
+		}},
+	}
+
+	_, err := repo.noSqlDB.UpdateByID(ctx, consts.MongoDBCollectionPlaylists, id, update)
+	if err != nil {
+		logger.FromContext(ctx).Error("IncrementForkCount", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return nil
+}
+
+// Delete a playlist by ID, its share links first so none is left when the playlist delete fails halfway
 func (repo *playlistRepository) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "playlistRepository.Delete")
+	defer span.End()
+	_, err := repo.noSqlDB.DeleteMany(ctx, consts.MongoDBCollectionPlaylistShares, bson.M{"playlist_id": id})
+	if err != nil {
+		logger.FromContext(ctx).Error("Delete", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
 	return repo.noSqlDB.DeleteByID(ctx, consts.MongoDBCollectionPlaylists, id)
 }
 
 // Search playlists
-func (repo *playlistRepository) Search(ctx context.Context, in model.Playlist) ([]model.Playlist, error) {
+func (repo *playlistRepository) Search(ctx context.Context, in model.Playlist, viewerUID string) ([]model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.Search")
+	defer span.End()
 	fiter := bson.M{}
 	if in.Title != "" {
 		fiter["title"] = bson.M{"$regex": in.Title, "$options": "i"}
@@ -102,19 +208,189 @@ func (repo *playlistRepository) Search(ctx context.Context, in model.Playlist) (
 	if in.Genre != "" {
 		fiter["genre"] = bson.M{"$regex": in.Genre, "$options": "i"}
 	}
+	fiter["$or"] = visibleTo(viewerUID)
 
 	cursor, err := repo.noSqlDB.Find(ctx, consts.MongoDBCollectionPlaylists, fiter)
 	if err != nil {
-		slog.Error(err.Error())
-		return nil, consts.CodeInternalError
+		logger.FromContext(ctx).Error("Search", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
 	}
 
 	var playlists []model.Playlist
 	err = cursor.All(ctx, &playlists)
 	if err != nil {
-		slog.Error(err.Error())
-		return nil, consts.CodeInternalError
+		logger.FromContext(ctx).Error("Search", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+
+	return playlists, nil
+}
+
+// List the forks of a playlist
+func (repo *playlistRepository) ListForks(ctx context.Context, id string, viewerUID string) ([]model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.ListForks")
+	defer span.End()
+	fiter := bson.M{
+		"forked_from.playlist_id": id,
+		"$or":                     visibleTo(viewerUID),
+	}
+
+	cursor, err := repo.noSqlDB.Find(ctx, consts.MongoDBCollectionPlaylists, fiter)
+	if err != nil {
+		logger.FromContext(ctx).Error("ListForks", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+
+	playlists := []model.Playlist{}
+	err = cursor.All(ctx, &playlists)
+	if err != nil {
+		logger.FromContext(ctx).Error("ListForks", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
 	}
 
 	return playlists, nil
 }
+
+// List the playlists a user owns or collaborates on, whatever their visibility
+func (repo *playlistRepository) ListByUser(ctx context.Context, uid string) ([]model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.ListByUser")
+	defer span.End()
+	fiter := bson.M{
+		"$or": bson.A{
+			bson.M{"created_by": uid},
+			bson.M{"collaborators.user_id": uid},
+		},
+	}
+
+	cursor, err := repo.noSqlDB.Find(ctx, consts.MongoDBCollectionPlaylists, fiter)
+	if err != nil {
+		logger.FromContext(ctx).Error("ListByUser", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+
+	playlists := []model.Playlist{}
+	err = cursor.All(ctx, &playlists)
+	if err != nil {
+		logger.FromContext(ctx).Error("ListByUser", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+	return playlists, nil
+}
+
+// Give a playlist to a new owner, who is not a collaborator anymore
+func (repo *playlistRepository) TransferOwnership(ctx context.Context, id string, newOwner string) error {
+	ctx, span := tracing.Start(ctx, "playlistRepository.TransferOwnership")
+	defer span.End()
+	update := bson.M{
+		"$set":  bson.M{"created_by": newOwner, "updated_at": time.Now()},
+		"$pull": bson.M{"collaborators": bson.M{"user_id": newOwner}},
+	}
+
+	_, err := repo.noSqlDB.UpdateByID(ctx, consts.MongoDBCollectionPlaylists, id, update)
+	if err != nil {
+		logger.FromContext(ctx).Error("TransferOwnership", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return nil
+}
+
+// Remove a user from the collaborators of every playlist
+func (repo *playlistRepository) RemoveCollaboratorFromAll(ctx context.Context, uid string) error {
+	ctx, span := tracing.Start(ctx, "playlistRepository.RemoveCollaboratorFromAll")
+	defer span.End()
+	update := bson.M{
+		"$pull": bson.M{"collaborators": bson.M{"user_id": uid}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	_, err := repo.noSqlDB.UpdateMany(ctx, consts.MongoDBCollectionPlaylists, bson.M{"collaborators.user_id": uid}, update)
+	if err != nil {
+		logger.FromContext(ctx).Error("RemoveCollaboratorFromAll", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return nil
+}
+
+// visibleTo matches public playlists and the playlists viewerUID owns or collaborates on
+// Playlists without visibility field are created before this feature, they are public
+func visibleTo(viewerUID string) bson.A {
+	return bson.A{
+		bson.M{"visibility": consts.PlaylistVisibilityPublic},
+		bson.M{"visibility": bson.M{"$exists": false}},
+		bson.M{"created_by": viewerUID},
+		bson.M{"collaborators.user_id": viewerUID},
+	}
+}
+
+// Create a share link of a playlist
+func (repo *playlistRepository) CreateShare(ctx context.Context, share model.PlaylistShare) (model.PlaylistShare, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.CreateShare")
+	defer span.End()
+	_, err := repo.noSqlDB.InsertOne(ctx, consts.MongoDBCollectionPlaylistShares, share)
+	if err != nil {
+		logger.FromContext(ctx).Error("CreateShare", "error", err)
+		return model.PlaylistShare{}, consts.CodeInternalError.Wrap(err)
+	}
+	return share, nil
+}
+
+// Get a share link by its token
+func (repo *playlistRepository) GetShareByToken(ctx context.Context, token string) (model.PlaylistShare, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.GetShareByToken")
+	defer span.End()
+	result, err := repo.noSqlDB.FindOne(ctx, consts.MongoDBCollectionPlaylistShares, bson.M{"token": token})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.PlaylistShare{}, consts.CodeShareTokenInvalid
+		}
+		logger.FromContext(ctx).Error("GetShareByToken", "error", err)
+		return model.PlaylistShare{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	var share model.PlaylistShare
+	err = result.Decode(&share)
+	if err != nil {
+		logger.FromContext(ctx).Error("GetShareByToken", "error", err)
+		return model.PlaylistShare{}, consts.CodeInternalError.Wrap(err)
+	}
+	return share, nil
+}
+
+// List all active share links of a playlist
+func (repo *playlistRepository) ListShares(ctx context.Context, playlistID string) ([]model.PlaylistShare, error) {
+	ctx, span := tracing.Start(ctx, "playlistRepository.ListShares")
+	defer span.End()
+	cursor, err := repo.noSqlDB.Find(ctx, consts.MongoDBCollectionPlaylistShares, bson.M{"playlist_id": playlistID})
+	if err != nil {
+		logger.FromContext(ctx).Error("ListShares", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+
+	shares := []model.PlaylistShare{}
+	err = cursor.All(ctx, &shares)
+	if err != nil {
+		logger.FromContext(ctx).Error("ListShares", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+	return shares, nil
+}
+
+// Revoke a share link. Revoked token is removed, so it can not be resolved anymore
+func (repo *playlistRepository) DeleteShare(ctx context.Context, playlistID string, token string) error {
+	ctx, span := tracing.Start(ctx, "playlistRepository.DeleteShare")
+	defer span.End()
+	share, err := repo.GetShareByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if share.PlaylistID != playlistID {
+		return consts.CodeShareTokenInvalid
+	}
+
+	err = repo.noSqlDB.DeleteByID(ctx, consts.MongoDBCollectionPlaylistShares, share.ID.Hex())
+	if err != nil {
+		logger.FromContext(ctx).Error("DeleteShare", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package token_repository
+
+import (
+	"context"
+	"emvn/consts"
+	"emvn/database/nosql"
+	"emvn/internal/model"
+	"emvn/pkg/logger"
+	"emvn/pkg/tracing"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ITokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token model.RefreshToken) (model.RefreshToken, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (model.RefreshToken, error)
+	// MarkRefreshTokenUsed returns false when the token has already been used or revoked
+	// It is atomic, so two concurrent refresh with the same token can not both succeed
+	MarkRefreshTokenUsed(ctx context.Context, token model.RefreshToken) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID string) error
+	CreateOIDCState(ctx context.Context, state model.OIDCState) error
+	// ConsumeOIDCState returns the state and marks it used, a state can only be consumed once
+	ConsumeOIDCState(ctx context.Context, stateHash string) (model.OIDCState, error)
+	CreatePasswordResetToken(ctx context.Context, token model.PasswordResetToken) error
+	// ConsumePasswordResetToken returns the token and marks it used, a token can only be consumed once
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (model.PasswordResetToken, error)
+}
+
+type tokenRepository struct {
+	noSqlDB nosql.NoSQLInterface
+}
+
+// Singleton pattern
+var localTokenRepository ITokenRepository
+
+func InitTokenRepository(noSqlDB nosql.NoSQLInterface) {
+	localTokenRepository = &tokenRepository{
+		noSqlDB: noSqlDB,
+	}
+}
+
+func TokenRepository() ITokenRepository {
+	return localTokenRepository
+}
+
+func (r *tokenRepository) CreateRefreshToken(ctx context.Context, token model.RefreshToken) (model.RefreshToken, error) {
+	ctx, span := tracing.Start(ctx, "tokenRepository.CreateRefreshToken")
+	defer span.End()
+	_, err := r.noSqlDB.InsertOne(ctx, consts.MongoDBCollectionRefreshTokens, token)
+	if err != nil {
+		logger.FromContext(ctx).Error("CreateRefreshToken", "error", err)
+		return model.RefreshToken{}, consts.CodeInternalError.Wrap(err)
+	}
+	return token, nil
+}
+
+func (r *tokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (model.RefreshToken, error) {
+	ctx, span := tracing.Start(ctx, "tokenRepository.GetRefreshTokenByHash")
+	defer span.End()
+	result, err := r.noSqlDB.FindOne(ctx, consts.MongoDBCollectionRefreshTokens, bson.M{"token_hash": tokenHash})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.RefreshToken{}, consts.CodeInvalidRefreshToken
+		}
+		logger.FromContext(ctx).Error("GetRefreshTokenByHash", "error", err)
+		return model.RefreshToken{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	var token model.RefreshToken
+	err = result.Decode(&token)
+	if err != nil {
+		logger.FromContext(ctx).Error("GetRefreshTokenByHash", "error", err)
+		return model.RefreshToken{}, consts.CodeInternalError.Wrap(err)
+	}
+	return token, nil
+}
+
+func (r *tokenRepository) MarkRefreshTokenUsed(ctx context.Context, token model.RefreshToken) (bool, error) {
+	ctx, span := tracing.Start(ctx, "tokenRepository.MarkRefreshTokenUsed")
+	defer span.End()
+	filter := bson.M{
+		"_id":        token.ID,
+		"used_at":    nil,
+		"revoked_at": nil,
+	}
+	update := bson.M{"$set": bson.M{"used_at": time.Now()}}
+
+	result, err := r.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionRefreshTokens, filter, update)
+	if err != nil {
+		logger.FromContext(ctx).Error("MarkRefreshTokenUsed", "error", err)
+		return false, consts.CodeInternalError.Wrap(err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *tokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ctx, span := tracing.Start(ctx, "tokenRepository.RevokeRefreshTokenFamily")
+	defer span.End()
+	return r.revoke(ctx, bson.M{"family_id": familyID, "revoked_at": nil})
+}
+
+func (r *tokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "tokenRepository.RevokeUserRefreshTokens")
+	defer span.End()
+	return r.revoke(ctx, bson.M{"user_id": userID, "revoked_at": nil})
+}
+
+func (r *tokenRepository) revoke(ctx context.Context, filter bson.M) error {
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+	_, err := r.noSqlDB.UpdateMany(ctx, consts.MongoDBCollectionRefreshTokens, filter, update)
+	if err != nil {
+		logger.FromContext(ctx).Error("revoke", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return nil
+}
+
+func (r *tokenRepository) CreateOIDCState(ctx context.Context, state model.OIDCState) error {
+	ctx, span := tracing.Start(ctx, "tokenRepository.CreateOIDCState")
+	defer span.End()
+	_, err := r.noSqlDB.InsertOne(ctx, consts.MongoDBCollectionOIDCStates, state)
+	if err != nil {
+		logger.FromContext(ctx).Error("CreateOIDCState", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return nil
+}
+
+func (r *tokenRepository) ConsumeOIDCState(ctx context.Context, stateHash string) (model.OIDCState, error) {
+	ctx, span := tracing.Start(ctx, "tokenRepository.ConsumeOIDCState")
+	defer span.End()
+	result, err := r.noSqlDB.FindOne(ctx, consts.MongoDBCollectionOIDCStates, bson.M{"state_hash": stateHash})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.OIDCState{}, consts.CodeOIDCStateInvalid
+		}
+		logger.FromContext(ctx).Error("ConsumeOIDCState", "error", err)
+		return model.OIDCState{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	var state model.OIDCState
+	err = result.Decode(&state)
+	if err != nil {
+		logger.FromContext(ctx).Error("ConsumeOIDCState", "error", err)
+		return model.OIDCState{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	// Same as refresh tokens, the update only matches an unused state so a replayed callback fails
+	updated, err := r.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionOIDCStates,
+		bson.M{"_id": state.ID, "used_at": nil}, bson.M{"$set": bson.M{"used_at": time.Now()}})
+	if err != nil {
+		logger.FromContext(ctx).Error("ConsumeOIDCState", "error", err)
+		return model.OIDCState{}, consts.CodeInternalError.Wrap(err)
+	}
+	if updated.ModifiedCount != 1 {
+		return model.OIDCState{}, consts.CodeOIDCStateInvalid
+	}
+	return state, nil
+}
+
+func (r *tokenRepository) CreatePasswordResetToken(ctx context.Context, token model.PasswordResetToken) error {
+	ctx, span := tracing.Start(ctx, "tokenRepository.CreatePasswordResetToken")
+	defer span.End()
+	_, err := r.noSqlDB.InsertOne(ctx, consts.MongoDBCollectionPasswordResets, token)
+	if err != nil {
+		logger.FromContext(ctx).Error("CreatePasswordResetToken", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return nil
+}
+
+func (r *tokenRepository) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (model.PasswordResetToken, error) {
+	ctx, span := tracing.Start(ctx, "tokenRepository.ConsumePasswordResetToken")
+	defer span.End()
+	result, err := r.noSqlDB.FindOne(ctx, consts.MongoDBCollectionPasswordResets, bson.M{"token_hash": tokenHash})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.PasswordResetToken{}, consts.CodeInvalidResetToken
+		}
+		logger.FromContext(ctx).Error("ConsumePasswordResetToken", "error", err)
+		return model.PasswordResetToken{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	var token model.PasswordResetToken
+	err = result.Decode(&token)
+	if err != nil {
+		logger.FromContext(ctx).Error("ConsumePasswordResetToken", "error", err)
+		return model.PasswordResetToken{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	updated, err := r.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionPasswordResets,
+		bson.M{"_id": token.ID, "used_at": nil}, bson.M{"$set": bson.M{"used_at": time.Now()}})
+	if err != nil {
+		logger.FromContext(ctx).Error("ConsumePasswordResetToken", "error", err)
+		return model.PasswordResetToken{}, consts.CodeInternalError.Wrap(err)
+	}
+	if updated.ModifiedCount != 1 {
+		return model.PasswordResetToken{}, consts.CodeInvalidResetToken
+	}
+	return token, nil
+}
@@ -5,15 +5,24 @@ import (
 	"emvn/consts"
 	"emvn/database/nosql"
 	"emvn/internal/model"
-	"fmt"
-	"log/slog"
+	"emvn/pkg/logger"
+	"emvn/pkg/tracing"
+	"errors"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type IUserRepository interface {
 	CreateUser(ctx context.Context, user model.User) (model.User, error)
 	GetUserByUsername(ctx context.Context, username string) (model.User, error)
+	GetUserByID(ctx context.Context, id string) (model.User, error)
+	ListUsers(ctx context.Context) ([]model.User, error)
+	UpdateRole(ctx context.Context, id string, role consts.UserRole) (model.User, error)
+	GetUserByOIDC(ctx context.Context, identity model.UserIdentity) (model.User, error)
+	UpdatePassword(ctx context.Context, id string, passwordHash string) error
+	UpdateProfile(ctx context.Context, id string, profile model.UserProfile) (model.User, error)
+	DeleteUser(ctx context.Context, id string) error
 }
 
 type userRepository struct {
@@ -34,23 +43,30 @@ func UserRepository() IUserRepository {
 }
 
 func (r *userRepository) CreateUser(ctx context.Context, user model.User) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userRepository.CreateUser")
+	defer span.End()
 	result, err := r.noSqlDB.CreateIfNotExists(ctx, consts.MongoDBCollectionUsers, bson.M{"username": user.Username}, user)
 	if err != nil {
-		slog.Error("CreateUser", "error", err)
+		logger.FromContext(ctx).Error("CreateUser", "error", err)
 		return model.User{}, err
 	}
 	err = result.Decode(&user)
 	if err != nil {
-		slog.Error("CreateUser", "error", err)
+		logger.FromContext(ctx).Error("CreateUser", "error", err)
 		return model.User{}, err
 	}
 	return user, nil
 }
 
 func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userRepository.GetUserByUsername")
+	defer span.End()
 	result, err := r.noSqlDB.FindOne(ctx, consts.MongoDBCollectionUsers, bson.M{"username": username})
 	if err != nil {
-		fmt.Println("GetUserByUsername", "error", err)
+		// No user is an expected answer, e.g. when signing up
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			logger.FromContext(ctx).Error("GetUserByUsername", "error", err)
+		}
 		return model.User{}, err
 	}
 
@@ -61,3 +77,125 @@ func (r *userRepository) GetUserByUsername(ctx context.Context, username string)
 	}
 	return user, nil
 }
+
+func (r *userRepository) GetUserByID(ctx context.Context, id string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userRepository.GetUserByID")
+	defer span.End()
+	result, err := r.noSqlDB.FindOne(ctx, consts.MongoDBCollectionUsers, bson.M{"id": id})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.User{}, consts.CodeUserNotFound
+		}
+		logger.FromContext(ctx).Error("GetUserByID", "error", err)
+		return model.User{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	var user model.User
+	err = result.Decode(&user)
+	if err != nil {
+		logger.FromContext(ctx).Error("GetUserByID", "error", err)
+		return model.User{}, consts.CodeInternalError.Wrap(err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) ListUsers(ctx context.Context) ([]model.User, error) {
+	ctx, span := tracing.Start(ctx, "userRepository.ListUsers")
+	defer span.End()
+	cursor, err := r.noSqlDB.Find(ctx, consts.MongoDBCollectionUsers, bson.M{})
+	if err != nil {
+		logger.FromContext(ctx).Error("ListUsers", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+
+	users := []model.User{}
+	err = cursor.All(ctx, &users)
+	if err != nil {
+		logger.FromContext(ctx).Error("ListUsers", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+	return users, nil
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id string, role consts.UserRole) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userRepository.UpdateRole")
+	defer span.End()
+	result, err := r.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionUsers, bson.M{"id": id}, bson.M{"$set": bson.M{"role": role}})
+	if err != nil {
+		logger.FromContext(ctx).Error("UpdateRole", "error", err)
+		return model.User{}, consts.CodeInternalError.Wrap(err)
+	}
+	if result.MatchedCount == 0 {
+		return model.User{}, consts.CodeUserNotFound
+	}
+	return r.GetUserByID(ctx, id)
+}
+
+func (r *userRepository) GetUserByOIDC(ctx context.Context, identity model.UserIdentity) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userRepository.GetUserByOIDC")
+	defer span.End()
+	filter := bson.M{"oidc.issuer": identity.Issuer, "oidc.subject": identity.Subject}
+	result, err := r.noSqlDB.FindOne(ctx, consts.MongoDBCollectionUsers, filter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.User{}, consts.CodeUserNotFound
+		}
+		logger.FromContext(ctx).Error("GetUserByOIDC", "error", err)
+		return model.User{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	var user model.User
+	err = result.Decode(&user)
+	if err != nil {
+		logger.FromContext(ctx).Error("GetUserByOIDC", "error", err)
+		return model.User{}, consts.CodeInternalError.Wrap(err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	ctx, span := tracing.Start(ctx, "userRepository.UpdatePassword")
+	defer span.End()
+	result, err := r.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionUsers, bson.M{"id": id}, bson.M{"$set": bson.M{"password": passwordHash}})
+	if err != nil {
+		logger.FromContext(ctx).Error("UpdatePassword", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	if result.MatchedCount == 0 {
+		return consts.CodeUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, id string, profile model.UserProfile) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userRepository.UpdateProfile")
+	defer span.End()
+	update := bson.M{"$set": bson.M{
+		"display_name": profile.DisplayName,
+		"email":        profile.Email,
+		"avatar_url":   profile.AvatarURL,
+	}}
+	result, err := r.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionUsers, bson.M{"id": id}, update)
+	if err != nil {
+		logger.FromContext(ctx).Error("UpdateProfile", "error", err)
+		return model.User{}, consts.CodeInternalError.Wrap(err)
+	}
+	if result.MatchedCount == 0 {
+		return model.User{}, consts.CodeUserNotFound
+	}
+	return r.GetUserByID(ctx, id)
+}
+
+func (r *userRepository) DeleteUser(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "userRepository.DeleteUser")
+	defer span.End()
+	result, err := r.noSqlDB.DeleteOne(ctx, consts.MongoDBCollectionUsers, bson.M{"id": id})
+	if err != nil {
+		logger.FromContext(ctx).Error("DeleteUser", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	if result.DeletedCount == 0 {
+		return consts.CodeUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package apikey_usecase
+
+import (
+	"context"
+	"emvn/consts"
+	"emvn/internal/model"
+	apikey_repository "emvn/internal/repository/api_key"
+	user_repository "emvn/internal/repository/user"
+	"emvn/pkg/logger"
+	"emvn/pkg/tracing"
+	"emvn/utility"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Every key starts with this prefix, so a leaked key is easy to recognize in logs or code
+const keyPrefix = "emvn_"
+
+// Last used timestamp is only written when it is older than this, to avoid a write on every request
+const lastUsedResolution = time.Minute
+
+type IAPIKeyUsecase interface {
+	// Create a key acting on behalf of uid. Scopes must be granted by the role of uid
+	Create(ctx context.Context, uid string, role consts.UserRole, name string, scopes []consts.Permission) (CreateAPIKeyOutput, error)
+	List(ctx context.Context, uid string) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id string, uid string) error
+	// Verify returns the key when it is valid and records when it has been used
+	// The scopes of the key are limited to the current permissions of its owner, so demoting the owner demotes the key
+	Verify(ctx context.Context, key string) (model.APIKey, error)
+}
+
+type apiKeyUsecase struct {
+	repo     apikey_repository.IAPIKeyRepository
+	userRepo user_repository.IUserRepository
+}
+
+// Singleton pattern
+var localAPIKeyUsecase IAPIKeyUsecase
+
+func InitAPIKeyUsecase(repo apikey_repository.IAPIKeyRepository, userRepo user_repository.IUserRepository) {
+	localAPIKeyUsecase = &apiKeyUsecase{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+func APIKeyUsecase() IAPIKeyUsecase {
+	return localAPIKeyUsecase
+}
+
+func (uc *apiKeyUsecase) Create(ctx context.Context, uid string, role consts.UserRole, name string, scopes []consts.Permission) (CreateAPIKeyOutput, error) {
+	ctx, span := tracing.Start(ctx, "apiKeyUsecase.Create")
+	defer span.End()
+	granted := role.Permissions()
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return CreateAPIKeyOutput{}, consts.CodeInvalidScope
+		}
+	}
+
+	secret, err := utility.GenerateRandomToken(32)
+	if err != nil {
+		logger.FromContext(ctx).Error("Create", "error", err)
+		return CreateAPIKeyOutput{}, consts.CodeInternalError.Wrap(err)
+	}
+	key := keyPrefix + secret
+
+	dbKey, err := uc.repo.Create(ctx, model.APIKey{
+		ID:        primitive.NewObjectID(),
+		UserID:    uid,
+		Name:      name,
+		Prefix:    key[:len(keyPrefix)+6],
+		KeyHash:   utility.HashToken(key),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return CreateAPIKeyOutput{}, err
+	}
+
+	return CreateAPIKeyOutput{
+		APIKey: dbKey,
+		Key:    key,
+	}, nil
+}
+
+func (uc *apiKeyUsecase) List(ctx context.Context, uid string) ([]model.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "apiKeyUsecase.List")
+	defer span.End()
+	return uc.repo.ListByUser(ctx, uid)
+}
+
+func (uc *apiKeyUsecase) Revoke(ctx context.Context, id string, uid string) error {
+	ctx, span := tracing.Start(ctx, "apiKeyUsecase.Revoke")
+	defer span.End()
+	return uc.repo.Revoke(ctx, id, uid)
+}
+
+func (uc *apiKeyUsecase) Verify(ctx context.Context, key string) (model.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "apiKeyUsecase.Verify")
+	defer span.End()
+	if !strings.HasPrefix(key, keyPrefix) {
+		return model.APIKey{}, consts.CodeInvalidAPIKey
+	}
+
+	dbKey, err := uc.repo.GetByHash(ctx, utility.HashToken(key))
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	// A key of a deleted owner is not valid anymore
+	owner, err := uc.userRepo.GetUserByID(ctx, dbKey.UserID)
+	if err != nil {
+		if errors.Is(err, consts.CodeUserNotFound) {
+			return model.APIKey{}, consts.CodeInvalidAPIKey
+		}
+		return model.APIKey{}, err
+	}
+	granted := owner.GetRole().Permissions()
+	scopes := make([]consts.Permission, 0, len(dbKey.Scopes))
+	for _, scope := range dbKey.Scopes {
+		if slices.Contains(granted, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	dbKey.Scopes = scopes
+
+	now := time.Now()
+	if dbKey.LastUsedAt == nil || now.Sub(*dbKey.LastUsedAt) > lastUsedResolution {
+		// The request should not fail because of the bookkeeping
+		if err := uc.repo.UpdateLastUsed(ctx, dbKey.ID, now); err != nil {
+			logger.FromContext(ctx).Error("Verify", "error", err)
+		}
+		dbKey.LastUsedAt = &now
+	}
+	return dbKey, nil
+}
@@ -0,0 +1,9 @@
+package apikey_usecase
+
+import "emvn/internal/model"
+
+// CreateAPIKeyOutput is the only time the plain key is returned
+type CreateAPIKeyOutput struct {
+	model.APIKey
+	Key string `json:"key"`
+}
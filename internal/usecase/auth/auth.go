@@ -4,9 +4,21 @@ import (
 	"context"
 	"emvn/consts"
 	"emvn/internal/model"
+	token_repository "emvn/internal/repository/token"
 	user_repository "emvn/internal/repository/user"
+	"emvn/pkg/keyset"
+	"emvn/pkg/logger"
+	"emvn/pkg/loginguard"
+	"emvn/pkg/notifier"
+	"emvn/pkg/oidc"
+	"emvn/pkg/password"
+	"emvn/pkg/revocation"
+	"emvn/pkg/tracing"
+	"emvn/utility"
+	"errors"
 	"fmt"
-	"log/slog"
+	"sync"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -14,18 +26,55 @@ import (
 
 type IAuthUsecase interface {
 	SignUp(ctx context.Context, user model.User) error
-	SignIn(ctx context.Context, username, password string) (SignInOutput, error)
+	// SignIn may return a ThrottledError, its RetryAfter should be sent to the client
+	SignIn(ctx context.Context, username, password, ip string) (SignInOutput, error)
+	// Refresh rotates a refresh token and issues a new access token
+	Refresh(ctx context.Context, refreshToken string) (SignInOutput, error)
+	// VerifyAccessToken checks the signature, the expiration and that the token has not been revoked
+	VerifyAccessToken(ctx context.Context, token string) (AccessToken, error)
+	// Logout revokes the access token and the refresh tokens of its session
+	Logout(ctx context.Context, claims AccessToken) error
+	// LogoutAll revokes every access token and refresh token of the user
+	LogoutAll(ctx context.Context, uid string) error
+	// JWKS returns the public keys verifying the access tokens
+	JWKS() keyset.JWKS
+	// OIDCLoginURL starts a single sign on and returns the URL of the issuer
+	OIDCLoginURL(ctx context.Context) (string, error)
+	// OIDCCallback finishes a single sign on and signs the user in
+	OIDCCallback(ctx context.Context, code, state string) (SignInOutput, error)
+	// ChangePassword requires the current password
+	ChangePassword(ctx context.Context, uid, currentPassword, newPassword string) error
+	// RequestPasswordReset sends a single use reset token to the user with the notifier
+	RequestPasswordReset(ctx context.Context, username string) error
+	// ResetPassword sets a new password with a reset token and revokes every session of the user
+	ResetPassword(ctx context.Context, token, newPassword string) error
 }
 
 type authUsecase struct {
-	userRepo user_repository.IUserRepository
+	userRepo        user_repository.IUserRepository
+	tokenRepo       token_repository.ITokenRepository
+	revocationStore revocation.Store
+	// nil when single sign on is disabled
+	oidcProvider *oidc.Provider
+	notifier     notifier.Notifier
+	loginGuard   *loginguard.Guard
+
+	passwordHasher *password.Hasher
+	dummyHashOnce  sync.Once
+	dummyHash      string
 }
 
 var localAuthUsecase IAuthUsecase
 
-func InitAuthUsecase(userRepo user_repository.IUserRepository) {
+func InitAuthUsecase(userRepo user_repository.IUserRepository, tokenRepo token_repository.ITokenRepository, revocationStore revocation.Store, oidcProvider *oidc.Provider, notifier notifier.Notifier, loginGuard *loginguard.Guard, passwordHasher *password.Hasher) {
 	localAuthUsecase = &authUsecase{
-		userRepo: userRepo,
+		userRepo:        userRepo,
+		tokenRepo:       tokenRepo,
+		revocationStore: revocationStore,
+		oidcProvider:    oidcProvider,
+		notifier:        notifier,
+		loginGuard:      loginGuard,
+		passwordHasher:  passwordHasher,
 	}
 }
 
@@ -34,11 +83,13 @@ func AuthUsecase() IAuthUsecase {
 }
 
 func (u *authUsecase) SignUp(ctx context.Context, user model.User) error {
+	ctx, span := tracing.Start(ctx, "authUsecase.SignUp")
+	defer span.End()
 	// check if user already exists
 	dbUser, err := u.userRepo.GetUserByUsername(ctx, user.Username)
 	if err != nil {
-		if err.Error() != mongo.ErrNoDocuments.Error() {
-			return consts.CodeInternalError
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return consts.CodeInternalError.Wrap(err)
 		}
 	}
 
@@ -46,45 +97,205 @@ func (u *authUsecase) SignUp(ctx context.Context, user model.User) error {
 		return consts.CodeUserAlreadyExists
 	}
 	// Add hash password
-	user.Password, err = hashPassword(user.Password)
+	user.Password, err = u.passwordHasher.Hash(user.Password)
 	if err != nil {
-		slog.Error(err.Error())
-		return consts.CodeInternalError
+		if errors.Is(err, password.ErrTooLong) {
+			return consts.CodePasswordTooLong
+		}
+		logger.FromContext(ctx).Error("SignUp", "error", err)
+		return consts.CodeInternalError.Wrap(err)
 	}
 
 	user.ID = primitive.NewObjectID().Hex()
+	// Every new user is a listener, admins are promoted out of band with cmd/promote
+	user.Role = consts.UserRoleListener
 	_, err = u.userRepo.CreateUser(ctx, user)
 	if err != nil {
-		return consts.CodeInternalError
+		return consts.CodeInternalError.Wrap(err)
 	}
 
 	return nil
 }
 
-func (u *authUsecase) SignIn(ctx context.Context, username, password string) (SignInOutput, error) {
+// SignIn returns the same error for an unknown user and a wrong password, so it does not tell which usernames exist
+// Failures are tracked per username and per client IP, repeated failures are delayed then locked
+func (u *authUsecase) SignIn(ctx context.Context, username, password, ip string) (SignInOutput, error) {
+	ctx, span := tracing.Start(ctx, "authUsecase.SignIn")
+	defer span.End()
 	var output SignInOutput
-	dbUser, err := u.userRepo.GetUserByUsername(ctx, username)
+	decision, err := u.loginGuard.Check(ctx, username, ip)
 	if err != nil {
-		if err.Error() == mongo.ErrNoDocuments.Error() {
-			return output, consts.CodeUserNotFound
+		logger.FromContext(ctx).Error("SignIn", "error", err)
+		return output, consts.CodeInternalError.Wrap(err)
+	}
+	if decision.RetryAfter > 0 {
+		if decision.Locked {
+			return output, ThrottledError{CustomError: consts.CodeAccountLocked, RetryAfter: decision.RetryAfter}
 		}
+		return output, ThrottledError{CustomError: consts.CodeTooManyAttempts, RetryAfter: decision.RetryAfter}
+	}
+
+	dbUser, err := u.userRepo.GetUserByUsername(ctx, username)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
 		return output, err
 	}
-	if !checkPasswordHash(password, dbUser.Password) {
-		return output, consts.CodeWrongPassword
+	// An unknown user still pays for a hash comparison, so the response time does not tell either
+	if err != nil || !u.checkPassword(ctx, dbUser, password) {
+		if err != nil {
+			u.passwordHasher.Verify(password, u.dummyPasswordHash())
+		}
+		if err := u.loginGuard.Fail(ctx, username, ip); err != nil {
+			logger.FromContext(ctx).Error("SignIn", "error", err)
+		}
+		return output, consts.CodeInvalidCredentials
+	}
+	if err := u.loginGuard.Succeed(ctx, username); err != nil {
+		logger.FromContext(ctx).Error("SignIn", "error", err)
+	}
+
+	// Every sign in starts a new refresh token family
+	return u.issueTokens(ctx, dbUser, primitive.NewObjectID().Hex())
+}
+
+// Refresh a session. The refresh token is single use, a new one of the same family is returned
+// When a used token is presented again, it has probably been stolen, so the whole family is revoked
+func (u *authUsecase) Refresh(ctx context.Context, refreshToken string) (SignInOutput, error) {
+	ctx, span := tracing.Start(ctx, "authUsecase.Refresh")
+	defer span.End()
+	dbToken, err := u.tokenRepo.GetRefreshTokenByHash(ctx, utility.HashToken(refreshToken))
+	if err != nil {
+		return SignInOutput{}, err
+	}
+	if dbToken.RevokedAt != nil {
+		return SignInOutput{}, consts.CodeInvalidRefreshToken
+	}
+	if dbToken.UsedAt != nil {
+		return SignInOutput{}, u.revokeReusedFamily(ctx, dbToken)
+	}
+	if time.Now().After(dbToken.ExpiresAt) {
+		return SignInOutput{}, consts.CodeRefreshTokenExpired
+	}
+
+	ok, err := u.tokenRepo.MarkRefreshTokenUsed(ctx, dbToken)
+	if err != nil {
+		return SignInOutput{}, err
+	}
+	if !ok {
+		// Another request used it in the meantime
+		return SignInOutput{}, u.revokeReusedFamily(ctx, dbToken)
+	}
+
+	// Read the user again so a role change is applied
+	dbUser, err := u.userRepo.GetUserByID(ctx, dbToken.UserID)
+	if err != nil {
+		return SignInOutput{}, err
+	}
+
+	return u.issueTokens(ctx, dbUser, dbToken.FamilyID)
+}
+
+func (u *authUsecase) VerifyAccessToken(ctx context.Context, token string) (AccessToken, error) {
+	ctx, span := tracing.Start(ctx, "authUsecase.VerifyAccessToken")
+	defer span.End()
+	acToken := AccessToken{}
+	err := acToken.Verify(ctx, token)
+	if err != nil {
+		return AccessToken{}, consts.CodeInvalidToken
 	}
 
+	// Tokens issued before jti was introduced can not be revoked one by one, they are still covered by LogoutAll
+	if acToken.Jti != "" {
+		revoked, err := u.revocationStore.IsRevoked(ctx, acToken.Jti)
+		if err != nil {
+			logger.FromContext(ctx).Error("VerifyAccessToken", "error", err)
+			return AccessToken{}, consts.CodeInternalError.Wrap(err)
+		}
+		if revoked {
+			return AccessToken{}, consts.CodeTokenRevoked
+		}
+	}
+
+	revokedBefore, err := u.revocationStore.RevokedBefore(ctx, acToken.Sub)
+	if err != nil {
+		logger.FromContext(ctx).Error("VerifyAccessToken", "error", err)
+		return AccessToken{}, consts.CodeInternalError.Wrap(err)
+	}
+	if !revokedBefore.IsZero() && acToken.IssuedBefore(revokedBefore) {
+		return AccessToken{}, consts.CodeTokenRevoked
+	}
+
+	return acToken, nil
+}
+
+func (u *authUsecase) Logout(ctx context.Context, claims AccessToken) error {
+	ctx, span := tracing.Start(ctx, "authUsecase.Logout")
+	defer span.End()
+	if claims.Jti != "" {
+		err := u.revocationStore.Revoke(ctx, claims.Jti, time.Unix(claims.Exp, 0))
+		if err != nil {
+			logger.FromContext(ctx).Error("Logout", "error", err)
+			return consts.CodeInternalError.Wrap(err)
+		}
+	}
+	if claims.Sid != "" {
+		return u.tokenRepo.RevokeRefreshTokenFamily(ctx, claims.Sid)
+	}
+	return nil
+}
+
+func (u *authUsecase) LogoutAll(ctx context.Context, uid string) error {
+	ctx, span := tracing.Start(ctx, "authUsecase.LogoutAll")
+	defer span.End()
+	err := u.revocationStore.RevokeAllBefore(ctx, uid, time.Now())
+	if err != nil {
+		logger.FromContext(ctx).Error("LogoutAll", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return u.tokenRepo.RevokeUserRefreshTokens(ctx, uid)
+}
+
+func (u *authUsecase) revokeReusedFamily(ctx context.Context, dbToken model.RefreshToken) error {
+	logger.FromContext(ctx).Warn("refresh token reused, revoking family", "user_id", dbToken.UserID, "family_id", dbToken.FamilyID)
+	if err := u.tokenRepo.RevokeRefreshTokenFamily(ctx, dbToken.FamilyID); err != nil {
+		return err
+	}
+	return consts.CodeRefreshTokenReused
+}
+
+// issueTokens generates an access token and a refresh token of the given family
+func (u *authUsecase) issueTokens(ctx context.Context, user model.User, familyID string) (SignInOutput, error) {
+	var output SignInOutput
+
 	// Gen Access Token
 	acToken := AccessToken{
-		Sub: fmt.Sprintf("%v", dbUser.ID),
-		Iss: fmt.Sprintf("%v", dbUser.ID),
+		Sub:  fmt.Sprintf("%v", user.ID),
+		Iss:  fmt.Sprintf("%v", user.ID),
+		Sid:  familyID,
+		Role: user.GetRole(),
 	}
 	acTokenString, err := acToken.Gen()
 	if err != nil {
 		return output, err
 	}
 
+	// Gen Refresh Token
+	refreshToken, dbToken, err := genRefreshToken(user.ID, familyID)
+	if err != nil {
+		logger.FromContext(ctx).Error("issueTokens", "error", err)
+		return output, consts.CodeInternalError.Wrap(err)
+	}
+	_, err = u.tokenRepo.CreateRefreshToken(ctx, dbToken)
+	if err != nil {
+		return output, err
+	}
+
 	output.Token = acTokenString
 	output.Exp = acToken.Exp
+	output.RefreshToken = refreshToken
+	output.RefreshExp = dbToken.ExpiresAt.Unix()
 	return output, nil
 }
+
+func (uc *authUsecase) JWKS() keyset.JWKS {
+	return utility.JWKS()
+}
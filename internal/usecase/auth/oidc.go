@@ -0,0 +1,171 @@
+package auth_usecase
+
+import (
+	"context"
+	"emvn/config"
+	"emvn/consts"
+	"emvn/internal/model"
+	"emvn/pkg/logger"
+	"emvn/pkg/oidc"
+	"emvn/pkg/tracing"
+	"emvn/utility"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const defaultOIDCStateExpireTime = 10 * time.Minute
+
+// OIDCLoginURL starts a single sign on and returns the URL of the issuer to redirect the user to
+func (u *authUsecase) OIDCLoginURL(ctx context.Context) (string, error) {
+	ctx, span := tracing.Start(ctx, "authUsecase.OIDCLoginURL")
+	defer span.End()
+	if u.oidcProvider == nil {
+		return "", consts.CodeOIDCDisabled
+	}
+
+	state, err := utility.GenerateRandomToken(32)
+	if err != nil {
+		logger.FromContext(ctx).Error("OIDCLoginURL", "error", err)
+		return "", consts.CodeInternalError.Wrap(err)
+	}
+	nonce, err := utility.GenerateRandomToken(32)
+	if err != nil {
+		logger.FromContext(ctx).Error("OIDCLoginURL", "error", err)
+		return "", consts.CodeInternalError.Wrap(err)
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		logger.FromContext(ctx).Error("OIDCLoginURL", "error", err)
+		return "", consts.CodeInternalError.Wrap(err)
+	}
+
+	expireTime := time.Duration(config.GetConfig().OIDC.StateExpireMinute) * time.Minute
+	if expireTime <= 0 {
+		expireTime = defaultOIDCStateExpireTime
+	}
+	now := time.Now()
+	err = u.tokenRepo.CreateOIDCState(ctx, model.OIDCState{
+		ID:           primitive.NewObjectID(),
+		StateHash:    utility.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(expireTime),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url, err := u.oidcProvider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		logger.FromContext(ctx).Error("OIDCLoginURL", "error", err)
+		return "", consts.CodeOIDCLoginFailed
+	}
+	return url, nil
+}
+
+// OIDCCallback finishes a single sign on and issues the tokens of the user linked to the identity
+func (u *authUsecase) OIDCCallback(ctx context.Context, code, state string) (SignInOutput, error) {
+	ctx, span := tracing.Start(ctx, "authUsecase.OIDCCallback")
+	defer span.End()
+	if u.oidcProvider == nil {
+		return SignInOutput{}, consts.CodeOIDCDisabled
+	}
+
+	dbState, err := u.tokenRepo.ConsumeOIDCState(ctx, utility.HashToken(state))
+	if err != nil {
+		return SignInOutput{}, err
+	}
+	if time.Now().After(dbState.ExpiresAt) {
+		return SignInOutput{}, consts.CodeOIDCStateInvalid
+	}
+
+	claims, err := u.oidcProvider.Exchange(ctx, code, dbState.CodeVerifier, dbState.Nonce)
+	if err != nil {
+		logger.FromContext(ctx).Warn("oidc exchange failed", "error", err)
+		return SignInOutput{}, consts.CodeOIDCLoginFailed
+	}
+
+	user, err := u.resolveOIDCUser(ctx, claims)
+	if err != nil {
+		return SignInOutput{}, err
+	}
+
+	return u.issueTokens(ctx, user, primitive.NewObjectID().Hex())
+}
+
+// resolveOIDCUser returns the user linked to the identity, by issuer and subject only
+// An unknown identity gets a new user when the config allows it. It is never linked to a local user by its username:
+// the issuer controls the username claim, it could take over any account
+func (u *authUsecase) resolveOIDCUser(ctx context.Context, claims oidc.Claims) (model.User, error) {
+	identity := model.UserIdentity{
+		Issuer:  u.oidcProvider.Issuer(),
+		Subject: claims.String("sub"),
+	}
+	user, err := u.userRepo.GetUserByOIDC(ctx, identity)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, consts.CodeUserNotFound) {
+		return model.User{}, err
+	}
+
+	cfg := config.GetConfig().OIDC
+	usernameClaim := cfg.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = "preferred_username"
+	}
+	username := claims.String(usernameClaim)
+	if username == "" {
+		logger.FromContext(ctx).Warn("oidc identity has no username claim", "claim", usernameClaim, "subject", identity.Subject)
+		return model.User{}, consts.CodeOIDCUserNotAllowed
+	}
+
+	if !cfg.AutoProvision {
+		return model.User{}, consts.CodeOIDCUserNotAllowed
+	}
+
+	username, err = u.availableUsername(ctx, username)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	// No password, the user can only sign in with single sign on
+	return u.userRepo.CreateUser(ctx, model.User{
+		ID:       primitive.NewObjectID().Hex(),
+		Username: username,
+		Role:     consts.UserRoleListener,
+		OIDC:     &identity,
+	})
+}
+
+// Attempts to find a free username for a provisioned user before giving up
+const maxUsernameAttempts = 5
+
+// availableUsername returns username, or username with a random suffix when a local user has it already
+// The identity gets an account either way, so the provisioning does not tell which local usernames exist
+func (u *authUsecase) availableUsername(ctx context.Context, username string) (string, error) {
+	candidate := username
+	for i := 0; i < maxUsernameAttempts; i++ {
+		_, err := u.userRepo.GetUserByUsername(ctx, candidate)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return candidate, nil
+		}
+		if err != nil {
+			logger.FromContext(ctx).Error("availableUsername", "error", err)
+			return "", consts.CodeInternalError.Wrap(err)
+		}
+
+		suffix, err := utility.GenerateRandomToken(4)
+		if err != nil {
+			logger.FromContext(ctx).Error("availableUsername", "error", err)
+			return "", consts.CodeInternalError.Wrap(err)
+		}
+		candidate = username + "-" + suffix
+	}
+	logger.FromContext(ctx).Error("availableUsername", "error", "no free username", "username", username)
+	return "", consts.CodeOIDCUserNotAllowed
+}
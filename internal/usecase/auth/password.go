@@ -0,0 +1,119 @@
+package auth_usecase
+
+import (
+	"context"
+	"emvn/config"
+	"emvn/consts"
+	"emvn/internal/model"
+	"emvn/pkg/logger"
+	"emvn/pkg/notifier"
+	"emvn/pkg/password"
+	"emvn/pkg/tracing"
+	"emvn/utility"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const defaultPasswordResetExpireTime = 30 * time.Minute
+
+func (u *authUsecase) ChangePassword(ctx context.Context, uid, currentPassword, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "authUsecase.ChangePassword")
+	defer span.End()
+	dbUser, err := u.userRepo.GetUserByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !u.checkPassword(ctx, dbUser, currentPassword) {
+		return consts.CodeWrongPassword
+	}
+	return u.setPassword(ctx, uid, newPassword)
+}
+
+// RequestPasswordReset sends a reset token to the user
+// It succeeds when the user does not exist or the token can not be sent, so it can not be used to find out which usernames exist
+func (u *authUsecase) RequestPasswordReset(ctx context.Context, username string) error {
+	ctx, span := tracing.Start(ctx, "authUsecase.RequestPasswordReset")
+	defer span.End()
+	dbUser, err := u.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		logger.FromContext(ctx).Error("RequestPasswordReset", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+
+	if err := u.sendPasswordReset(ctx, dbUser); err != nil {
+		logger.FromContext(ctx).Error("RequestPasswordReset", "error", err)
+	}
+	return nil
+}
+
+func (u *authUsecase) sendPasswordReset(ctx context.Context, dbUser model.User) error {
+	token, err := utility.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	expireTime := time.Duration(config.GetConfig().Auth.PasswordResetExpireTime) * time.Minute
+	if expireTime <= 0 {
+		expireTime = defaultPasswordResetExpireTime
+	}
+	now := time.Now()
+	err = u.tokenRepo.CreatePasswordResetToken(ctx, model.PasswordResetToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    dbUser.ID,
+		TokenHash: utility.HashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(expireTime),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Users without email get it by their username, the notifier knows how to reach them
+	to := dbUser.Email
+	if to == "" {
+		to = dbUser.Username
+	}
+	return u.notifier.Send(ctx, notifier.Message{
+		To:      to,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Use this token to reset your password, it expires in %s:\n%s", expireTime, token),
+	})
+}
+
+// ResetPassword sets a new password with a reset token, then revokes every session of the user
+func (u *authUsecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "authUsecase.ResetPassword")
+	defer span.End()
+	dbToken, err := u.tokenRepo.ConsumePasswordResetToken(ctx, utility.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if time.Now().After(dbToken.ExpiresAt) {
+		return consts.CodeInvalidResetToken
+	}
+
+	if err := u.setPassword(ctx, dbToken.UserID, newPassword); err != nil {
+		return err
+	}
+	// Someone else may know the old password
+	return u.LogoutAll(ctx, dbToken.UserID)
+}
+
+func (u *authUsecase) setPassword(ctx context.Context, uid, newPassword string) error {
+	hash, err := u.passwordHasher.Hash(newPassword)
+	if err != nil {
+		if errors.Is(err, password.ErrTooLong) {
+			return consts.CodePasswordTooLong
+		}
+		logger.FromContext(ctx).Error("setPassword", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	return u.userRepo.UpdatePassword(ctx, uid, hash)
+}
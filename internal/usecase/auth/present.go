@@ -1,8 +1,26 @@
 package auth_usecase
 
+import (
+	"emvn/consts"
+	"time"
+)
+
 // Define the input and output of the usecase layer here
 
 type SignInOutput struct {
-	Token string `json:"token"`
-	Exp   int64  `json:"exp_time"`
+	Token        string `json:"token"`
+	Exp          int64  `json:"exp_time"`
+	RefreshToken string `json:"refresh_token"`
+	RefreshExp   int64  `json:"refresh_exp_time"`
+}
+
+// ThrottledError is returned when a sign in is refused for a while
+type ThrottledError struct {
+	consts.CustomError
+	RetryAfter time.Duration
+}
+
+// Unwrap lets apperror.From find the error sent to the client
+func (e ThrottledError) Unwrap() error {
+	return e.CustomError
 }
@@ -3,13 +3,16 @@ package auth_usecase
 import (
 	"context"
 	"emvn/config"
+	"emvn/consts"
+	"emvn/internal/model"
+	"emvn/pkg/logger"
+	"emvn/pkg/tracing"
 	"emvn/utility"
 	"encoding/json"
-	"log/slog"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Token interface {
@@ -21,11 +24,26 @@ type AccessToken struct {
 	Iss string `json:"iss"`
 	Sub string `json:"sub"`
 	Exp int64  `json:"exp"`
+	Iat int64  `json:"iat"`
+	Jti string `json:"jti"` // unique id of the token, used to revoke it
+	Sid string `json:"sid"` // session id, the refresh token family the token has been issued with
+	// Issue time in milliseconds, iat is in seconds and can not tell a token issued right after a logout of all sessions
+	// from a token issued right before it. Tokens issued before it was introduced do not have it
+	IatMs int64 `json:"iat_ms,omitempty"`
+	// Role of the user when the token has been issued. A role change is applied on the next refresh
+	Role consts.UserRole `json:"role"`
 }
 
 func (ac *AccessToken) Gen() (token string, err error) {
-	expTime := time.Now().Add(time.Duration(config.GetConfig().Auth.AccessTokenExpireTime) * time.Minute)
+	now := time.Now()
+	expTime := now.Add(time.Duration(config.GetConfig().Auth.AccessTokenExpireTime) * time.Minute)
 	ac.Exp = expTime.Unix()
+	ac.Iat = now.Unix()
+	ac.IatMs = now.UnixMilli()
+	ac.Jti, err = utility.GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
 
 	mapClaims := jwt.MapClaims{}
 	dataByte, _ := json.Marshal(ac)
@@ -35,28 +53,77 @@ func (ac *AccessToken) Gen() (token string, err error) {
 	return
 }
 
+// IssuedBefore tells whether the token was issued at or before t, the time of a logout of all sessions
+func (ac AccessToken) IssuedBefore(t time.Time) bool {
+	if ac.IatMs != 0 {
+		return ac.IatMs <= t.UnixMilli()
+	}
+	// Only in seconds, the token predates the millisecond claim and therefore the revocation in the same second
+	return ac.Iat <= t.Unix()
+}
+
 func (ac *AccessToken) Verify(ctx context.Context, token string) (err error) {
+	ctx, span := tracing.Start(ctx, "AccessToken.Verify")
+	defer span.End()
 	jwtMap, err := utility.ParseJWT(token)
 	if err != nil {
-		slog.Error(err.Error())
+		logger.FromContext(ctx).Error("Verify", "error", err)
 		return err
 	}
 
 	dataByte, _ := json.Marshal(jwtMap)
 	err = json.Unmarshal(dataByte, &ac)
 	if err != nil {
-		slog.Error(err.Error())
+		logger.FromContext(ctx).Error("Verify", "error", err)
 		return err
 	}
 	return nil
 }
 
-func hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+// genRefreshToken returns the plain token for the client and its hashed record for the database
+func genRefreshToken(userID string, familyID string) (string, model.RefreshToken, error) {
+	token, err := utility.GenerateRandomToken(32)
+	if err != nil {
+		return "", model.RefreshToken{}, err
+	}
+
+	now := time.Now()
+	return token, model.RefreshToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utility.HashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(config.GetConfig().Auth.RefreshTokenExpireTime) * time.Hour),
+	}, nil
+}
+
+// dummyPasswordHash is compared when the user does not exist, it is computed once when first needed
+func (u *authUsecase) dummyPasswordHash() string {
+	u.dummyHashOnce.Do(func() {
+		u.dummyHash, _ = u.passwordHasher.Hash("dummy password")
+	})
+	return u.dummyHash
 }
 
-func checkPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+// checkPassword verifies the password of the user. An outdated hash is replaced by a hash of the current algorithm,
+// it is the only time the plain password is known
+func (u *authUsecase) checkPassword(ctx context.Context, user model.User, password string) bool {
+	// Users created by single sign on have no password
+	if user.Password == "" {
+		return false
+	}
+	match, rehash, err := u.passwordHasher.Verify(password, user.Password)
+	if err != nil {
+		logger.FromContext(ctx).Error("checkPassword", "error", err)
+		return false
+	}
+	if rehash {
+		if hash, err := u.passwordHasher.Hash(password); err != nil {
+			logger.FromContext(ctx).Error("checkPassword", "error", err)
+		} else if err := u.userRepo.UpdatePassword(ctx, user.ID, hash); err != nil {
+			logger.FromContext(ctx).Error("checkPassword", "error", err)
+		}
+	}
+	return match
 }
@@ -0,0 +1,36 @@
+package auth_usecase
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccessTokenIssuedBefore(t *testing.T) {
+	revokedAt := time.Date(2026, time.October, 19, 12, 0, 0, 400*int(time.Millisecond), time.UTC)
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		legacy   bool // issued before the millisecond claim
+		want     bool
+	}{
+		{name: "issued in an earlier second", issuedAt: revokedAt.Add(-time.Second), want: true},
+		{name: "issued earlier in the same second", issuedAt: revokedAt.Add(-200 * time.Millisecond), want: true},
+		{name: "issued in the same millisecond", issuedAt: revokedAt, want: true},
+		{name: "issued later in the same second", issuedAt: revokedAt.Add(300 * time.Millisecond), want: false},
+		{name: "issued in a later second", issuedAt: revokedAt.Add(time.Second), want: false},
+		{name: "legacy issued in the same second", issuedAt: revokedAt.Add(300 * time.Millisecond), legacy: true, want: true},
+		{name: "legacy issued in a later second", issuedAt: revokedAt.Add(time.Second), legacy: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := AccessToken{Iat: tt.issuedAt.Unix()}
+			if !tt.legacy {
+				token.IatMs = tt.issuedAt.UnixMilli()
+			}
+			if got := token.IssuedBefore(revokedAt); got != tt.want {
+				t.Errorf("IssuedBefore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,7 +5,8 @@ import (
 	"emvn/consts"
 	"emvn/internal/model"
 	musictrack_repository "emvn/internal/repository/music_track"
-	"log/slog"
+	"emvn/pkg/logger"
+	"emvn/pkg/tracing"
 	"mime/multipart"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,7 +16,11 @@ type IMusicTrackUsecase interface {
 	CreateMusicTrack(ctx context.Context, in model.MusicTrack) (model.MusicTrack, error)
 	UploadTrack(ctx context.Context, file *multipart.FileHeader) (string, error)
 	GetMusicTrack(ctx context.Context, id string) (model.MusicTrack, error)
+	// StreamTrack returns the track with its audio file
+	StreamTrack(ctx context.Context, id string) (TrackFile, error)
 	UpdateMusicTrack(ctx context.Context, id string, in model.MusicTrack) (model.MusicTrack, error)
+	// PatchMusicTrack changes the fields set in patch only
+	PatchMusicTrack(ctx context.Context, id string, patch MusicTrackPatch) (model.MusicTrack, error)
 	DeleteMusicTrack(ctx context.Context, id string) error
 	SearchMusicTrack(ctx context.Context, in model.MusicTrack) ([]model.MusicTrack, error)
 }
@@ -37,21 +42,28 @@ func MusicTrackUsecase() IMusicTrackUsecase {
 }
 
 func (uc *musicTrackUsecase) CreateMusicTrack(ctx context.Context, in model.MusicTrack) (model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackUsecase.CreateMusicTrack")
+	defer span.End()
+	if !uc.musicTrackRepo.IsStoredFile(in.Link) {
+		return model.MusicTrack{}, consts.CodeInvalidTrackLink
+	}
 	in.ID = primitive.NewObjectID()
 	return uc.musicTrackRepo.Create(ctx, in)
 }
 
 func (uc *musicTrackUsecase) UploadTrack(ctx context.Context, file *multipart.FileHeader) (string, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackUsecase.UploadTrack")
+	defer span.End()
 	fileOpen, err := file.Open()
 	if err != nil {
-		slog.Error(err.Error())
+		logger.FromContext(ctx).Error("UploadTrack", "error", err)
 		return "", consts.CodeFileInvalid
 	}
 
 	fileBytes := make([]byte, file.Size)
 	_, err = fileOpen.Read(fileBytes)
 	if err != nil {
-		slog.Error(err.Error())
+		logger.FromContext(ctx).Error("UploadTrack", "error", err)
 		return "", consts.CodeFileInvalid
 	}
 
@@ -59,17 +71,82 @@ func (uc *musicTrackUsecase) UploadTrack(ctx context.Context, file *multipart.Fi
 }
 
 func (uc *musicTrackUsecase) GetMusicTrack(ctx context.Context, id string) (model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackUsecase.GetMusicTrack")
+	defer span.End()
 	return uc.musicTrackRepo.Get(ctx, id)
 }
 
+func (uc *musicTrackUsecase) StreamTrack(ctx context.Context, id string) (TrackFile, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackUsecase.StreamTrack")
+	defer span.End()
+	track, err := uc.musicTrackRepo.Get(ctx, id)
+	if err != nil {
+		return TrackFile{}, err
+	}
+	// The file is uploaded separately, a track may not have one yet
+	if track.Link == "" {
+		return TrackFile{}, consts.CodeFileNotFound
+	}
+
+	content, err := uc.musicTrackRepo.GetFile(ctx, track.Link)
+	if err != nil {
+		return TrackFile{}, err
+	}
+	return TrackFile{Track: track, Content: content}, nil
+}
+
 func (uc *musicTrackUsecase) UpdateMusicTrack(ctx context.Context, id string, in model.MusicTrack) (model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackUsecase.UpdateMusicTrack")
+	defer span.End()
+	if !uc.musicTrackRepo.IsStoredFile(in.Link) {
+		return model.MusicTrack{}, consts.CodeInvalidTrackLink
+	}
 	return uc.musicTrackRepo.Update(ctx, id, in)
 }
 
+func (uc *musicTrackUsecase) PatchMusicTrack(ctx context.Context, id string, patch MusicTrackPatch) (model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackUsecase.PatchMusicTrack")
+	defer span.End()
+	track, err := uc.musicTrackRepo.Get(ctx, id)
+	if err != nil {
+		return model.MusicTrack{}, err
+	}
+
+	if patch.Title != nil {
+		track.Title = *patch.Title
+	}
+	if patch.Artist != nil {
+		track.Artist = *patch.Artist
+	}
+	if patch.Album != nil {
+		track.Album = *patch.Album
+	}
+	if patch.Genre != nil {
+		track.Genre = *patch.Genre
+	}
+	if patch.Year != nil {
+		track.Year = *patch.Year
+	}
+	if patch.Duration != nil {
+		track.Duration = *patch.Duration
+	}
+	if patch.Link != nil {
+		if !uc.musicTrackRepo.IsStoredFile(*patch.Link) {
+			return model.MusicTrack{}, consts.CodeInvalidTrackLink
+		}
+		track.Link = *patch.Link
+	}
+	return uc.musicTrackRepo.Update(ctx, id, track)
+}
+
 func (uc *musicTrackUsecase) DeleteMusicTrack(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "musicTrackUsecase.DeleteMusicTrack")
+	defer span.End()
 	return uc.musicTrackRepo.Delete(ctx, id)
 }
 
 func (uc *musicTrackUsecase) SearchMusicTrack(ctx context.Context, in model.MusicTrack) ([]model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "musicTrackUsecase.SearchMusicTrack")
+	defer span.End()
 	return uc.musicTrackRepo.Search(ctx, in)
 }
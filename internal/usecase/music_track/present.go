@@ -1,5 +1,7 @@
 package musictrack_usecase
 
+import "emvn/internal/model"
+
 type CreateMusicTrackInput struct {
 	Title    string   `bson:"title" json:"title"`
 	Artists  []string `bson:"artists" json:"artists"`
@@ -9,3 +11,20 @@ type CreateMusicTrackInput struct {
 	Duration int      `bson:"duration" json:"duration"`
 	Link     string   `bson:"link" json:"link"`
 }
+
+// TrackFile is a track with the content of its audio file
+type TrackFile struct {
+	Track   model.MusicTrack
+	Content []byte
+}
+
+// MusicTrackPatch holds the fields to change, nil fields are kept
+type MusicTrackPatch struct {
+	Title    *string
+	Artist   *string
+	Album    *string
+	Genre    *string
+	Year     *int
+	Duration *int
+	Link     *string
+}
@@ -0,0 +1,178 @@
+package playlist_usecase
+
+import (
+	"context"
+	"emvn/consts"
+	"emvn/internal/model"
+	"emvn/pkg/logger"
+	"emvn/pkg/tracing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Fork a playlist. The fork starts private, without collaborators, and keeps who added each track upstream
+func (usecase *playlistUsecase) Fork(ctx context.Context, id string, uid string) (PlaylistWithTracks, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.Fork")
+	defer span.End()
+	source, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleViewer)
+	if err != nil {
+		return PlaylistWithTracks{}, err
+	}
+
+	now := time.Now()
+	trackIDs := source.TrackIDs
+	if trackIDs == nil {
+		trackIDs = []string{}
+	}
+	fork := model.Playlist{
+		ID:            primitive.NewObjectID(),
+		Title:         source.Title,
+		Description:   source.Description,
+		Genre:         source.Genre,
+		TrackIDs:      trackIDs,
+		Visibility:    consts.PlaylistVisibilityPrivate,
+		Type:          source.GetType(),
+		Rules:         source.Rules,
+		CreatedBy:     uid,
+		Collaborators: []model.PlaylistCollaborator{},
+		Entries:       source.EntriesOrDefault(),
+		ForkedFrom: &model.PlaylistFork{
+			PlaylistID:   id,
+			ForkedAt:     now,
+			SyncedAt:     now,
+			BaseTrackIDs: trackIDs,
+		},
+	}
+
+	forkDB, err := usecase.repo.Create(ctx, fork)
+	if err != nil {
+		return PlaylistWithTracks{}, err
+	}
+	if err := usecase.repo.IncrementForkCount(ctx, id, 1); err != nil {
+		logger.FromContext(ctx).Error("Fork", "error", err)
+	}
+
+	return usecase.withTracks(ctx, forkDB)
+}
+
+// List the forks of a playlist the caller can see
+func (usecase *playlistUsecase) ListForks(ctx context.Context, id string, uid string) ([]model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.ListForks")
+	defer span.End()
+	if _, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleViewer); err != nil {
+		return nil, err
+	}
+
+	forks, err := usecase.repo.ListForks(ctx, id, uid)
+	if err != nil {
+		return nil, err
+	}
+	// omitting track_ids, same as search
+	for i := range forks {
+		forks[i].TrackIDs = nil
+		forks[i].Entries = nil
+		forks[i].Visibility = forks[i].GetVisibility()
+		hideCollaborators(&forks[i], uid)
+	}
+	return forks, nil
+}
+
+// Sync a fork with its upstream with a three way merge of the track lists
+// Tracks added upstream since the base are appended, tracks removed upstream since the base are removed
+// Changes made on the fork are kept. Smart fork takes the rules of the upstream
+func (usecase *playlistUsecase) SyncFork(ctx context.Context, id string, uid string) (SyncForkOutput, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.SyncFork")
+	defer span.End()
+	fork, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleEditor)
+	if err != nil {
+		return SyncForkOutput{}, err
+	}
+	if fork.ForkedFrom == nil {
+		return SyncForkOutput{}, consts.CodePlaylistNotFork
+	}
+	upstream, err := usecase.authorize(ctx, fork.ForkedFrom.PlaylistID, uid, consts.PlaylistRoleViewer)
+	if err != nil {
+		return SyncForkOutput{}, err
+	}
+
+	local := toSet(fork.TrackIDs)
+	trackIDs, added, removed := mergeTrackIDs(fork.ForkedFrom.BaseTrackIDs, fork.TrackIDs, upstream.TrackIDs)
+	output := SyncForkOutput{Added: added, Removed: removed}
+
+	// Pulled tracks keep who added them upstream
+	upstreamEntries := make(map[string]model.PlaylistEntry, len(upstream.TrackIDs))
+	for _, entry := range upstream.EntriesOrDefault() {
+		upstreamEntries[entry.TrackID] = entry
+	}
+	entries := mergeEntries(fork.EntriesOrDefault(), trackIDs, uid)
+	for i := range entries {
+		if entry, ok := upstreamEntries[entries[i].TrackID]; ok && !local[entries[i].TrackID] {
+			entries[i] = entry
+		}
+	}
+
+	fork.TrackIDs = trackIDs
+	fork.Entries = entries
+	fork.Visibility = fork.GetVisibility()
+	if upstream.GetType() == consts.PlaylistTypeSmart && fork.GetType() == consts.PlaylistTypeSmart {
+		fork.Rules = upstream.Rules
+	}
+	fork.Type = fork.GetType()
+	if _, err := usecase.repo.Update(ctx, id, fork); err != nil {
+		return SyncForkOutput{}, err
+	}
+
+	upstreamTrackIDs := upstream.TrackIDs
+	if upstreamTrackIDs == nil {
+		upstreamTrackIDs = []string{}
+	}
+	synced, err := usecase.repo.UpdateForkedFrom(ctx, id, model.PlaylistFork{
+		PlaylistID:   fork.ForkedFrom.PlaylistID,
+		ForkedAt:     fork.ForkedFrom.ForkedAt,
+		SyncedAt:     time.Now(),
+		BaseTrackIDs: upstreamTrackIDs,
+	})
+	if err != nil {
+		return SyncForkOutput{}, err
+	}
+
+	output.Playlist, err = usecase.withTracks(ctx, synced)
+	if err != nil {
+		return SyncForkOutput{}, err
+	}
+	return output, nil
+}
+
+// mergeTrackIDs merges the track lists of a fork and its upstream, base is the upstream list at the last sync.
+// The order of the fork is kept, the tracks added upstream are appended in the upstream order
+func mergeTrackIDs(base, local, upstream []string) (trackIDs, added, removed []string) {
+	baseSet := toSet(base)
+	localSet := toSet(local)
+	upstreamSet := toSet(upstream)
+
+	added, removed = []string{}, []string{}
+	trackIDs = make([]string, 0, len(local))
+	for _, trackID := range local {
+		if baseSet[trackID] && !upstreamSet[trackID] {
+			removed = append(removed, trackID)
+			continue
+		}
+		trackIDs = append(trackIDs, trackID)
+	}
+	for _, trackID := range upstream {
+		if !baseSet[trackID] && !localSet[trackID] {
+			added = append(added, trackID)
+			trackIDs = append(trackIDs, trackID)
+		}
+	}
+	return trackIDs, added, removed
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
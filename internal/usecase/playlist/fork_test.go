@@ -0,0 +1,112 @@
+package playlist_usecase
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeTrackIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        []string
+		local       []string
+		upstream    []string
+		wantTracks  []string
+		wantAdded   []string
+		wantRemoved []string
+	}{
+		{
+			name:        "nothing changed",
+			base:        []string{"a", "b"},
+			local:       []string{"a", "b"},
+			upstream:    []string{"a", "b"},
+			wantTracks:  []string{"a", "b"},
+			wantAdded:   []string{},
+			wantRemoved: []string{},
+		},
+		{
+			name:        "added upstream",
+			base:        []string{"a", "b"},
+			local:       []string{"a", "b"},
+			upstream:    []string{"c", "a", "b"},
+			wantTracks:  []string{"a", "b", "c"},
+			wantAdded:   []string{"c"},
+			wantRemoved: []string{},
+		},
+		{
+			name:        "removed upstream",
+			base:        []string{"a", "b", "c"},
+			local:       []string{"c", "b", "a"},
+			upstream:    []string{"a", "c"},
+			wantTracks:  []string{"c", "a"},
+			wantAdded:   []string{},
+			wantRemoved: []string{"b"},
+		},
+		{
+			name:        "added locally is kept",
+			base:        []string{"a"},
+			local:       []string{"x", "a"},
+			upstream:    []string{"a"},
+			wantTracks:  []string{"x", "a"},
+			wantAdded:   []string{},
+			wantRemoved: []string{},
+		},
+		{
+			name:        "removed locally is not pulled again",
+			base:        []string{"a", "b"},
+			local:       []string{"a"},
+			upstream:    []string{"a", "b"},
+			wantTracks:  []string{"a"},
+			wantAdded:   []string{},
+			wantRemoved: []string{},
+		},
+		{
+			name:        "added on both sides",
+			base:        []string{"a"},
+			local:       []string{"a", "x"},
+			upstream:    []string{"a", "x", "y"},
+			wantTracks:  []string{"a", "x", "y"},
+			wantAdded:   []string{"y"},
+			wantRemoved: []string{},
+		},
+		{
+			name:        "removed on both sides",
+			base:        []string{"a", "b"},
+			local:       []string{"a"},
+			upstream:    []string{"a"},
+			wantTracks:  []string{"a"},
+			wantAdded:   []string{},
+			wantRemoved: []string{},
+		},
+		{
+			name:        "no base pulls every missing track",
+			local:       []string{"x", "a"},
+			upstream:    []string{"a", "b"},
+			wantTracks:  []string{"x", "a", "b"},
+			wantAdded:   []string{"b"},
+			wantRemoved: []string{},
+		},
+		{
+			name:        "upstream emptied",
+			base:        []string{"a", "b"},
+			local:       []string{"a", "x", "b"},
+			wantTracks:  []string{"x"},
+			wantAdded:   []string{},
+			wantRemoved: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, added, removed := mergeTrackIDs(tt.base, tt.local, tt.upstream)
+			if !reflect.DeepEqual(tracks, tt.wantTracks) {
+				t.Errorf("track ids = %v, want %v", tracks, tt.wantTracks)
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("added = %v, want %v", added, tt.wantAdded)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
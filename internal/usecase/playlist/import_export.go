@@ -0,0 +1,185 @@
+package playlist_usecase
+
+import (
+	"context"
+	"emvn/consts"
+	"emvn/internal/model"
+	"emvn/pkg/logger"
+	"emvn/pkg/playlistformat"
+	"emvn/pkg/tracing"
+	"emvn/pkg/validator"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Route streaming the audio of a track, the location of the exported entries
+const (
+	streamPathPrefix = "/v1/tracks/"
+	streamPathSuffix = "/audio"
+)
+
+func streamURL(baseURL string, trackID string) string {
+	return strings.TrimSuffix(baseURL, "/") + streamPathPrefix + trackID + streamPathSuffix
+}
+
+// trackIDFromStreamURL returns the track id of a location written by Export, or an empty string
+// The host is not checked, a playlist exported by another deployment of the API is matched the same way
+func trackIDFromStreamURL(location string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return ""
+	}
+	i := strings.LastIndex(u.Path, streamPathPrefix)
+	if i < 0 || !strings.HasSuffix(u.Path, streamPathSuffix) {
+		return ""
+	}
+	id := strings.TrimSuffix(u.Path[i+len(streamPathPrefix):], streamPathSuffix)
+	if !validator.IsMongoObjectId(id) {
+		return ""
+	}
+	return id
+}
+
+// Export a playlist, smart playlist is exported with the tracks it currently evaluates to
+func (usecase *playlistUsecase) Export(ctx context.Context, id string, format playlistformat.Format, baseURL string, uid string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.Export")
+	defer span.End()
+	dbPlaylist, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	playlist, err := usecase.withTracks(ctx, dbPlaylist)
+	if err != nil {
+		return nil, err
+	}
+
+	out := playlistformat.Playlist{
+		Title:       playlist.Title,
+		Description: playlist.Description,
+		Entries:     make([]playlistformat.Entry, 0, len(playlist.Tracks)),
+	}
+	for _, track := range playlist.Tracks {
+		out.Entries = append(out.Entries, playlistformat.Entry{
+			ID:       track.ID.Hex(),
+			Title:    track.Title,
+			Artist:   track.Artist,
+			Album:    track.Album,
+			Duration: track.Duration,
+			Location: streamURL(baseURL, track.ID.Hex()),
+		})
+	}
+
+	data, err := playlistformat.Encode(format, out)
+	if err != nil {
+		if errors.Is(err, playlistformat.ErrUnsupportedFormat) {
+			return nil, consts.CodeUnsupportedFormat
+		}
+		logger.FromContext(ctx).Error("Export", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+	return data, nil
+}
+
+// Import a playlist file. Each entry is matched by track id or stream URL, then by title and artist, then by file name
+// Entries which do not match any track are listed in the report, the playlist is created with the matched tracks
+func (usecase *playlistUsecase) Import(ctx context.Context, in ImportPlaylistInput, uid string) (ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.Import")
+	defer span.End()
+	file, err := playlistformat.Decode(in.Format, in.Data)
+	if err != nil {
+		if errors.Is(err, playlistformat.ErrUnsupportedFormat) {
+			return ImportReport{}, consts.CodeUnsupportedFormat
+		}
+		return ImportReport{}, consts.CodePlaylistFileInvalid
+	}
+
+	report := ImportReport{
+		Total:     len(file.Entries),
+		Matched:   []MatchedEntry{},
+		Unmatched: []UnmatchedEntry{},
+	}
+	trackIDs := []string{}
+	added := map[string]bool{}
+	for i, entry := range file.Entries {
+		track, method, err := usecase.matchEntry(ctx, entry)
+		if err != nil {
+			if errors.Is(err, consts.CodeMusicTrackNotFound) {
+				report.Unmatched = append(report.Unmatched, UnmatchedEntry{Index: i, Entry: entry})
+				continue
+			}
+			return ImportReport{}, err
+		}
+
+		trackID := track.ID.Hex()
+		report.Matched = append(report.Matched, MatchedEntry{Index: i, TrackID: trackID, MatchedBy: method})
+		// A track is only once in a playlist
+		if !added[trackID] {
+			added[trackID] = true
+			trackIDs = append(trackIDs, trackID)
+		}
+	}
+
+	title := in.Title
+	if title == "" {
+		title = file.Title
+	}
+	if title == "" {
+		title = "Imported playlist"
+	}
+
+	report.Playlist, err = usecase.Create(ctx, model.Playlist{
+		Title:       title,
+		Description: file.Description,
+		Genre:       in.Genre,
+		TrackIDs:    trackIDs,
+		Visibility:  in.Visibility,
+		Type:        consts.PlaylistTypeStatic,
+	}, uid)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	return report, nil
+}
+
+// matchEntry returns CodeMusicTrackNotFound when the entry matches no track
+func (usecase *playlistUsecase) matchEntry(ctx context.Context, entry playlistformat.Entry) (model.MusicTrack, MatchMethod, error) {
+	// m3u8 and xspf files only keep the location, the id is in the stream URL
+	streamID := trackIDFromStreamURL(entry.Location)
+	if entry.ID == "" {
+		entry.ID = streamID
+	}
+	if entry.ID != "" && validator.IsMongoObjectId(entry.ID) {
+		tracks, err := usecase.musicRepo.GetByIDs(ctx, []string{entry.ID})
+		if err != nil {
+			return model.MusicTrack{}, "", err
+		}
+		if len(tracks) == 1 {
+			return tracks[0], MatchByID, nil
+		}
+	}
+
+	if entry.Title != "" && entry.Artist != "" {
+		track, err := usecase.musicRepo.FindByTitleAndArtist(ctx, entry.Title, entry.Artist)
+		if err == nil {
+			return track, MatchByTitleArtist, nil
+		}
+		if !errors.Is(err, consts.CodeMusicTrackNotFound) {
+			return model.MusicTrack{}, "", err
+		}
+	}
+
+	// The last element of a stream URL is not a file name
+	if fileName := entry.FileName(); streamID == "" && fileName != "" && fileName != "." && fileName != "/" {
+		track, err := usecase.musicRepo.FindByFileName(ctx, fileName)
+		if err == nil {
+			return track, MatchByFileName, nil
+		}
+		if !errors.Is(err, consts.CodeMusicTrackNotFound) {
+			return model.MusicTrack{}, "", err
+		}
+	}
+
+	return model.MusicTrack{}, "", consts.CodeMusicTrackNotFound
+}
@@ -0,0 +1,47 @@
+package playlist_usecase
+
+import "testing"
+
+func TestStreamURLRoundTrip(t *testing.T) {
+	const id = "6650f1c2a1b2c3d4e5f60718"
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{baseURL: "", want: "/v1/tracks/" + id + "/audio"},
+		{baseURL: "https://api.emvn.example", want: "https://api.emvn.example/v1/tracks/" + id + "/audio"},
+		{baseURL: "https://emvn.example/api/", want: "https://emvn.example/api/v1/tracks/" + id + "/audio"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := streamURL(tt.baseURL, id)
+			if got != tt.want {
+				t.Fatalf("streamURL() = %q, want %q", got, tt.want)
+			}
+			if back := trackIDFromStreamURL(got); back != id {
+				t.Errorf("trackIDFromStreamURL() = %q, want %q", back, id)
+			}
+		})
+	}
+}
+
+func TestTrackIDFromStreamURL(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{location: "", want: ""},
+		{location: "/music/song.mp3", want: ""},
+		{location: "https://other.example/v1/tracks/6650f1c2a1b2c3d4e5f60718/audio?token=1", want: "6650f1c2a1b2c3d4e5f60718"},
+		{location: "/v1/tracks/not-an-id/audio", want: ""},
+		{location: "/v1/tracks/6650f1c2a1b2c3d4e5f60718", want: ""},
+		{location: "/v1/tracks/6650f1c2a1b2c3d4e5f60718/other/audio", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			if got := trackIDFromStreamURL(tt.location); got != tt.want {
+				t.Errorf("trackIDFromStreamURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
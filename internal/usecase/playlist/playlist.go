@@ -6,30 +6,69 @@ import (
 	"emvn/internal/model"
 	musictrack_repository "emvn/internal/repository/music_track"
 	playlist_repository "emvn/internal/repository/playlist"
+	user_repository "emvn/internal/repository/user"
+	"emvn/pkg/logger"
+	"emvn/pkg/playlistformat"
+	"emvn/pkg/tracing"
+	"emvn/utility"
+	"errors"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type IPlaylistUsecase interface {
 	Create(ctx context.Context, in model.Playlist, uid string) (PlaylistWithTracks, error)
-	Get(ctx context.Context, id string) (PlaylistWithTracks, error)
-	Update(ctx context.Context, id string, in model.Playlist) (PlaylistWithTracks, error)
-	Delete(ctx context.Context, id string) error
-	Search(ctx context.Context, in model.Playlist) ([]model.Playlist, error)
+	Get(ctx context.Context, id string, uid string) (PlaylistWithTracks, error)
+	Update(ctx context.Context, id string, in model.Playlist, uid string) (PlaylistWithTracks, error)
+	// Patch changes the fields set in patch only, with the checks of Update
+	Patch(ctx context.Context, id string, patch PlaylistPatch, uid string) (PlaylistWithTracks, error)
+	Delete(ctx context.Context, id string, uid string) error
+	Search(ctx context.Context, in model.Playlist, uid string) ([]model.Playlist, error)
+
+	// Share links, only the owner can manage them
+	CreateShare(ctx context.Context, id string, uid string) (model.PlaylistShare, error)
+	ListShares(ctx context.Context, id string, uid string) ([]model.PlaylistShare, error)
+	RevokeShare(ctx context.Context, id string, token string, uid string) error
+	// GetShared resolves a share token, it does not require an authenticated user
+	GetShared(ctx context.Context, token string) (PlaylistWithTracks, error)
+
+	// Freeze evaluates the rules of a smart playlist and turns it into a static playlist with those tracks
+	Freeze(ctx context.Context, id string, uid string) (PlaylistWithTracks, error)
+
+	// Export writes the playlist with track metadata, duration and stream URL in the given format
+	// The stream URL is the /v1 audio route under baseURL, a path relative to the API when baseURL is empty.
+	// The storage path of the files is never written
+	Export(ctx context.Context, id string, format playlistformat.Format, baseURL string, uid string) ([]byte, error)
+	// Import parses a playlist file, matches its entries to existing tracks and creates a new playlist owned by uid
+	Import(ctx context.Context, in ImportPlaylistInput, uid string) (ImportReport, error)
+
+	// Fork copies a playlist the caller can read into a new playlist owned by the caller
+	Fork(ctx context.Context, id string, uid string) (PlaylistWithTracks, error)
+	ListForks(ctx context.Context, id string, uid string) ([]model.Playlist, error)
+	// SyncFork pulls the changes made on the upstream playlist since the fork or the last sync
+	SyncFork(ctx context.Context, id string, uid string) (SyncForkOutput, error)
+
+	// Collaborators, only owners can manage them. A collaborator can remove themself
+	AddCollaborator(ctx context.Context, id string, username string, role consts.PlaylistRole, uid string) ([]model.PlaylistCollaborator, error)
+	RemoveCollaborator(ctx context.Context, id string, collaboratorUID string, uid string) ([]model.PlaylistCollaborator, error)
 }
 
 type playlistUsecase struct {
 	repo      playlist_repository.IPlaylistRepository
 	musicRepo musictrack_repository.IMusicTrackRepository
+	userRepo  user_repository.IUserRepository
 }
 
 // Singleton pattern
 var localPlaylistUsecase IPlaylistUsecase
 
-func InitPlaylistUsecase(repo playlist_repository.IPlaylistRepository, musicRepo musictrack_repository.IMusicTrackRepository) {
+func InitPlaylistUsecase(repo playlist_repository.IPlaylistRepository, musicRepo musictrack_repository.IMusicTrackRepository, userRepo user_repository.IUserRepository) {
 	localPlaylistUsecase = &playlistUsecase{
 		repo:      repo,
 		musicRepo: musicRepo,
+		userRepo:  userRepo,
 	}
 }
 
@@ -39,8 +78,19 @@ func PlaylistUsecase() IPlaylistUsecase {
 
 // Create a new playlist
 func (usecase *playlistUsecase) Create(ctx context.Context, in model.Playlist, uid string) (PlaylistWithTracks, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.Create")
+	defer span.End()
 	in.ID = primitive.NewObjectID()
 	in.CreatedBy = uid
+	if in.Visibility == "" {
+		in.Visibility = consts.PlaylistVisibilityPrivate
+	}
+	if in.Type == "" {
+		in.Type = consts.PlaylistTypeStatic
+	}
+	if err := prepareType(&in); err != nil {
+		return PlaylistWithTracks{}, err
+	}
 	// checking if track_ids is valid
 	// User can create a playlist without any track. they can add tracks later
 	if in.TrackIDs == nil {
@@ -57,45 +107,57 @@ func (usecase *playlistUsecase) Create(ctx context.Context, in model.Playlist, u
 		return PlaylistWithTracks{}, consts.CodeMusicTrackNotFound
 	}
 
+	in.Entries = mergeEntries(nil, in.TrackIDs, uid)
+	in.Collaborators = []model.PlaylistCollaborator{}
+
 	playlistDB, err := usecase.repo.Create(ctx, in)
 	if err != nil {
 		return PlaylistWithTracks{}, err
 	}
 
-	return PlaylistWithTracks{
-		ID:          playlistDB.ID,
-		Title:       playlistDB.Title,
-		Description: playlistDB.Description,
-		Genre:       playlistDB.Genre,
-		CreatedBy:   playlistDB.CreatedBy,
-		Tracks:      tracks,
-	}, nil
+	return usecase.withTracks(ctx, playlistDB)
 }
 
 // Get a playlist by ID
-func (usecase *playlistUsecase) Get(ctx context.Context, id string) (PlaylistWithTracks, error) {
-	dbPlaylist, err := usecase.repo.Get(ctx, id)
+// Private and unlisted playlists can only be read by collaborators, other users get not found so we don't leak their existence
+func (usecase *playlistUsecase) Get(ctx context.Context, id string, uid string) (PlaylistWithTracks, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.Get")
+	defer span.End()
+	dbPlaylist, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleViewer)
 	if err != nil {
 		return PlaylistWithTracks{}, err
 	}
+	hideCollaborators(&dbPlaylist, uid)
+
+	return usecase.withTracks(ctx, dbPlaylist)
+}
 
-	tracks, err := usecase.musicRepo.GetByIDs(ctx, dbPlaylist.TrackIDs)
+// Update a playlist by ID, editors and owners can update it
+// Visibility is kept as it is when the input does not set it, only owners can change it
+func (usecase *playlistUsecase) Update(ctx context.Context, id string, in model.Playlist, uid string) (PlaylistWithTracks, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.Update")
+	defer span.End()
+	dbPlaylist, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleEditor)
 	if err != nil {
 		return PlaylistWithTracks{}, err
 	}
+	if in.Visibility == "" {
+		in.Visibility = dbPlaylist.GetVisibility()
+	}
+	if in.Visibility != dbPlaylist.GetVisibility() && !dbPlaylist.RoleOf(uid).AtLeast(consts.PlaylistRoleOwner) {
+		return PlaylistWithTracks{}, consts.CodePlaylistForbidden
+	}
+	// Type and rules are kept as they are when the input does not set them
+	if in.Type == "" {
+		in.Type = dbPlaylist.GetType()
+	}
+	if in.Type == consts.PlaylistTypeSmart && in.Rules == nil {
+		in.Rules = dbPlaylist.Rules
+	}
+	if err := prepareType(&in); err != nil {
+		return PlaylistWithTracks{}, err
+	}
 
-	return PlaylistWithTracks{
-		ID:          dbPlaylist.ID,
-		Title:       dbPlaylist.Title,
-		Description: dbPlaylist.Description,
-		Genre:       dbPlaylist.Genre,
-		CreatedBy:   dbPlaylist.CreatedBy,
-		Tracks:      tracks,
-	}, nil
-}
-
-// Update a playlist by ID
-func (usecase *playlistUsecase) Update(ctx context.Context, id string, in model.Playlist) (PlaylistWithTracks, error) {
 	if in.TrackIDs == nil {
 		in.TrackIDs = []string{}
 	}
@@ -110,37 +172,434 @@ func (usecase *playlistUsecase) Update(ctx context.Context, id string, in model.
 		return PlaylistWithTracks{}, consts.CodeMusicTrackNotFound
 	}
 
+	in.Entries = mergeEntries(dbPlaylist.EntriesOrDefault(), in.TrackIDs, uid)
+
 	updatedPlaylist, err := usecase.repo.Update(ctx, id, in)
 	if err != nil {
 		return PlaylistWithTracks{}, err
 	}
 
-	return PlaylistWithTracks{
-		ID:          updatedPlaylist.ID,
-		Title:       updatedPlaylist.Title,
-		Description: updatedPlaylist.Description,
-		Genre:       updatedPlaylist.Genre,
-		CreatedBy:   updatedPlaylist.CreatedBy,
-		Tracks:      tracks,
-	}, nil
+	return usecase.withTracks(ctx, updatedPlaylist)
+}
+
+// Patch a playlist by ID, the playlist with the patch applied goes through Update
+func (usecase *playlistUsecase) Patch(ctx context.Context, id string, patch PlaylistPatch, uid string) (PlaylistWithTracks, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.Patch")
+	defer span.End()
+	dbPlaylist, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleEditor)
+	if err != nil {
+		return PlaylistWithTracks{}, err
+	}
+
+	in := model.Playlist{
+		Title:       dbPlaylist.Title,
+		Description: dbPlaylist.Description,
+		Genre:       dbPlaylist.Genre,
+		TrackIDs:    dbPlaylist.TrackIDs,
+		Visibility:  dbPlaylist.GetVisibility(),
+		Type:        dbPlaylist.GetType(),
+		Rules:       dbPlaylist.Rules,
+	}
+	if patch.Title != nil {
+		in.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		in.Description = *patch.Description
+	}
+	if patch.Genre != nil {
+		in.Genre = *patch.Genre
+	}
+	if patch.TrackIDs != nil {
+		in.TrackIDs = *patch.TrackIDs
+	}
+	if patch.Visibility != nil {
+		in.Visibility = *patch.Visibility
+	}
+	if patch.Type != nil {
+		in.Type = *patch.Type
+	}
+	if patch.Rules != nil {
+		in.Rules = patch.Rules
+	}
+	return usecase.Update(ctx, id, in, uid)
 }
 
-// Delete a playlist by ID
-func (usecase *playlistUsecase) Delete(ctx context.Context, id string) error {
-	return usecase.repo.Delete(ctx, id)
+// Delete a playlist by ID, only owners can delete it
+func (usecase *playlistUsecase) Delete(ctx context.Context, id string, uid string) error {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.Delete")
+	defer span.End()
+	dbPlaylist, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleOwner)
+	if err != nil {
+		return err
+	}
+	err = usecase.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if dbPlaylist.ForkedFrom != nil {
+		// The upstream may have been deleted, the count is only informative so we don't fail the delete
+		if err := usecase.repo.IncrementForkCount(ctx, dbPlaylist.ForkedFrom.PlaylistID, -1); err != nil {
+			logger.FromContext(ctx).Error("Delete", "error", err)
+		}
+	}
+	return nil
 }
 
 // I image this function is used to search for display purposes, so we don't need to return the tracks.
 // User can get tracks when they click on the playlist
-func (usecase *playlistUsecase) Search(ctx context.Context, in model.Playlist) ([]model.Playlist, error) {
-	playlists, err := usecase.repo.Search(ctx, in)
+// Only public playlists and the playlists the caller owns or collaborates on are returned
+func (usecase *playlistUsecase) Search(ctx context.Context, in model.Playlist, uid string) ([]model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.Search")
+	defer span.End()
+	playlists, err := usecase.repo.Search(ctx, in, uid)
 	if err != nil {
 		return nil, err
 	}
 	// omitting track_ids
 	for i := range playlists {
 		playlists[i].TrackIDs = nil
+		playlists[i].Entries = nil
+		playlists[i].Visibility = playlists[i].GetVisibility()
+		hideCollaborators(&playlists[i], uid)
 	}
 
 	return playlists, nil
 }
+
+// Create a new share link for a playlist, only owners can manage share links. Private playlist must be changed to unlisted or public before sharing
+func (usecase *playlistUsecase) CreateShare(ctx context.Context, id string, uid string) (model.PlaylistShare, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.CreateShare")
+	defer span.End()
+	dbPlaylist, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleOwner)
+	if err != nil {
+		return model.PlaylistShare{}, err
+	}
+	if dbPlaylist.GetVisibility() == consts.PlaylistVisibilityPrivate {
+		return model.PlaylistShare{}, consts.CodePlaylistPrivate
+	}
+
+	token, err := utility.GenerateRandomToken(32)
+	if err != nil {
+		logger.FromContext(ctx).Error("CreateShare", "error", err)
+		return model.PlaylistShare{}, consts.CodeInternalError.Wrap(err)
+	}
+
+	return usecase.repo.CreateShare(ctx, model.PlaylistShare{
+		ID:         primitive.NewObjectID(),
+		PlaylistID: id,
+		Token:      token,
+		CreatedBy:  uid,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// List share links of a playlist
+func (usecase *playlistUsecase) ListShares(ctx context.Context, id string, uid string) ([]model.PlaylistShare, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.ListShares")
+	defer span.End()
+	if _, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleOwner); err != nil {
+		return nil, err
+	}
+	return usecase.repo.ListShares(ctx, id)
+}
+
+// Revoke a share link of a playlist
+func (usecase *playlistUsecase) RevokeShare(ctx context.Context, id string, token string, uid string) error {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.RevokeShare")
+	defer span.End()
+	if _, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleOwner); err != nil {
+		return err
+	}
+	return usecase.repo.DeleteShare(ctx, id, token)
+}
+
+// Get a playlist by its share token
+// The token stops working as soon as the owner switches the playlist back to private
+func (usecase *playlistUsecase) GetShared(ctx context.Context, token string) (PlaylistWithTracks, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.GetShared")
+	defer span.End()
+	share, err := usecase.repo.GetShareByToken(ctx, token)
+	if err != nil {
+		return PlaylistWithTracks{}, err
+	}
+
+	dbPlaylist, err := usecase.repo.Get(ctx, share.PlaylistID)
+	if err != nil {
+		return PlaylistWithTracks{}, err
+	}
+	if dbPlaylist.GetVisibility() == consts.PlaylistVisibilityPrivate {
+		return PlaylistWithTracks{}, consts.CodeShareTokenInvalid
+	}
+	// Anyone with the link reads it, nobody is a collaborator
+	hideCollaborators(&dbPlaylist, "")
+
+	return usecase.withTracks(ctx, dbPlaylist)
+}
+
+// Freeze a smart playlist, the tracks it currently evaluates to are kept as a static playlist
+func (usecase *playlistUsecase) Freeze(ctx context.Context, id string, uid string) (PlaylistWithTracks, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.Freeze")
+	defer span.End()
+	dbPlaylist, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleEditor)
+	if err != nil {
+		return PlaylistWithTracks{}, err
+	}
+	if dbPlaylist.GetType() != consts.PlaylistTypeSmart || dbPlaylist.Rules == nil {
+		return PlaylistWithTracks{}, consts.CodePlaylistNotSmart
+	}
+
+	tracks, err := usecase.musicRepo.FindByRules(ctx, *dbPlaylist.Rules)
+	if err != nil {
+		return PlaylistWithTracks{}, err
+	}
+
+	trackIDs := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		trackIDs = append(trackIDs, track.ID.Hex())
+	}
+
+	dbPlaylist.Type = consts.PlaylistTypeStatic
+	dbPlaylist.Rules = nil
+	dbPlaylist.TrackIDs = trackIDs
+	dbPlaylist.Entries = mergeEntries(nil, trackIDs, uid)
+	dbPlaylist.Visibility = dbPlaylist.GetVisibility()
+
+	frozenPlaylist, err := usecase.repo.Update(ctx, id, dbPlaylist)
+	if err != nil {
+		return PlaylistWithTracks{}, err
+	}
+
+	return toPlaylistWithTracks(frozenPlaylist, tracks), nil
+}
+
+// Add a collaborator to a playlist by username. When the user is already a collaborator, their role is updated
+func (usecase *playlistUsecase) AddCollaborator(ctx context.Context, id string, username string, role consts.PlaylistRole, uid string) ([]model.PlaylistCollaborator, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.AddCollaborator")
+	defer span.End()
+	dbPlaylist, err := usecase.authorize(ctx, id, uid, consts.PlaylistRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := usecase.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, consts.CodeUserNotFound
+		}
+		logger.FromContext(ctx).Error("AddCollaborator", "error", err)
+		return nil, consts.CodeInternalError.Wrap(err)
+	}
+	if user.ID == dbPlaylist.CreatedBy {
+		return nil, consts.CodeCannotRemoveCreator
+	}
+
+	collaborators := dbPlaylist.Collaborators
+	found := false
+	for i := range collaborators {
+		if collaborators[i].UserID == user.ID {
+			collaborators[i].Role = role
+			found = true
+		}
+	}
+	if !found {
+		collaborators = append(collaborators, model.PlaylistCollaborator{
+			UserID:  user.ID,
+			Role:    role,
+			AddedBy: uid,
+			AddedAt: time.Now(),
+		})
+	}
+
+	updatedPlaylist, err := usecase.repo.UpdateCollaborators(ctx, id, collaborators)
+	if err != nil {
+		return nil, err
+	}
+	return updatedPlaylist.Collaborators, nil
+}
+
+// Remove a collaborator from a playlist
+func (usecase *playlistUsecase) RemoveCollaborator(ctx context.Context, id string, collaboratorUID string, uid string) ([]model.PlaylistCollaborator, error) {
+	ctx, span := tracing.Start(ctx, "playlistUsecase.RemoveCollaborator")
+	defer span.End()
+	required := consts.PlaylistRoleOwner
+	if collaboratorUID == uid {
+		required = consts.PlaylistRoleViewer
+	}
+	dbPlaylist, err := usecase.authorize(ctx, id, uid, required)
+	if err != nil {
+		return nil, err
+	}
+	if collaboratorUID == dbPlaylist.CreatedBy {
+		return nil, consts.CodeCannotRemoveCreator
+	}
+
+	collaborators := make([]model.PlaylistCollaborator, 0, len(dbPlaylist.Collaborators))
+	for _, collaborator := range dbPlaylist.Collaborators {
+		if collaborator.UserID != collaboratorUID {
+			collaborators = append(collaborators, collaborator)
+		}
+	}
+	if len(collaborators) == len(dbPlaylist.Collaborators) {
+		return nil, consts.CodeCollaboratorNotFound
+	}
+
+	updatedPlaylist, err := usecase.repo.UpdateCollaborators(ctx, id, collaborators)
+	if err != nil {
+		return nil, err
+	}
+	return updatedPlaylist.Collaborators, nil
+}
+
+// authorize returns the playlist when uid has at least the required role on it
+// Everyone is a viewer of a public playlist
+func (usecase *playlistUsecase) authorize(ctx context.Context, id string, uid string, required consts.PlaylistRole) (model.Playlist, error) {
+	dbPlaylist, err := usecase.repo.Get(ctx, id)
+	if err != nil {
+		return model.Playlist{}, err
+	}
+
+	role := dbPlaylist.RoleOf(uid)
+	isPublic := dbPlaylist.GetVisibility() == consts.PlaylistVisibilityPublic
+	if role.AtLeast(required) || (required == consts.PlaylistRoleViewer && isPublic) {
+		return dbPlaylist, nil
+	}
+	// Don't leak private playlists to users who can not see them
+	if !isPublic && !role.AtLeast(consts.PlaylistRoleViewer) {
+		return model.Playlist{}, consts.CodePlaylistNotFound
+	}
+	return model.Playlist{}, consts.CodePlaylistForbidden
+}
+
+// hideCollaborators removes the collaborators of a playlist uid does not own or collaborate on, only they can see who shares it
+func hideCollaborators(playlist *model.Playlist, uid string) {
+	if !playlist.RoleOf(uid).AtLeast(consts.PlaylistRoleViewer) {
+		playlist.Collaborators = nil
+	}
+}
+
+// withTracks loads the tracks of a playlist
+// Smart playlist is evaluated from its rules at read time, so it is always up to date
+func (usecase *playlistUsecase) withTracks(ctx context.Context, dbPlaylist model.Playlist) (PlaylistWithTracks, error) {
+	var (
+		tracks []model.MusicTrack
+		err    error
+	)
+	if dbPlaylist.GetType() == consts.PlaylistTypeSmart && dbPlaylist.Rules != nil {
+		tracks, err = usecase.musicRepo.FindByRules(ctx, *dbPlaylist.Rules)
+	} else {
+		tracks, err = usecase.musicRepo.GetByIDs(ctx, dbPlaylist.TrackIDs)
+	}
+	if err != nil {
+		return PlaylistWithTracks{}, err
+	}
+
+	return toPlaylistWithTracks(dbPlaylist, tracks), nil
+}
+
+// toPlaylistWithTracks hydrates the playlist with its tracks
+// Static playlist follows the order of TrackIDs and tracks which have been deleted are skipped
+// Smart playlist keeps the order of its rules and its tracks are not added by anyone
+func toPlaylistWithTracks(dbPlaylist model.Playlist, tracks []model.MusicTrack) PlaylistWithTracks {
+	playlistTracks := make([]PlaylistTrack, 0, len(tracks))
+	var lastModified time.Time
+	if dbPlaylist.GetType() == consts.PlaylistTypeSmart {
+		for _, track := range tracks {
+			playlistTracks = append(playlistTracks, PlaylistTrack{MusicTrack: track})
+		}
+	} else {
+		trackByID := make(map[string]model.MusicTrack, len(tracks))
+		for _, track := range tracks {
+			trackByID[track.ID.Hex()] = track
+		}
+		entryByID := make(map[string]model.PlaylistEntry, len(dbPlaylist.Entries))
+		for _, entry := range dbPlaylist.EntriesOrDefault() {
+			entryByID[entry.TrackID] = entry
+		}
+
+		for _, trackID := range dbPlaylist.TrackIDs {
+			track, ok := trackByID[trackID]
+			if !ok {
+				continue
+			}
+			entry := entryByID[trackID]
+			if track.LastModified().After(lastModified) {
+				lastModified = track.LastModified()
+			}
+			playlistTracks = append(playlistTracks, PlaylistTrack{
+				MusicTrack: track,
+				AddedBy:    entry.AddedBy,
+				AddedAt:    entry.AddedAt,
+			})
+		}
+	}
+
+	if dbPlaylist.GetType() != consts.PlaylistTypeSmart && dbPlaylist.LastModified().After(lastModified) {
+		lastModified = dbPlaylist.LastModified()
+	}
+
+	collaborators := dbPlaylist.Collaborators
+	if collaborators == nil {
+		collaborators = []model.PlaylistCollaborator{}
+	}
+
+	return PlaylistWithTracks{
+		ID:            dbPlaylist.ID,
+		Title:         dbPlaylist.Title,
+		Description:   dbPlaylist.Description,
+		Genre:         dbPlaylist.Genre,
+		Visibility:    dbPlaylist.GetVisibility(),
+		Type:          dbPlaylist.GetType(),
+		Rules:         dbPlaylist.Rules,
+		ForkedFrom:    dbPlaylist.ForkedFrom,
+		ForkCount:     dbPlaylist.ForkCount,
+		CreatedBy:     dbPlaylist.CreatedBy,
+		Tracks:        playlistTracks,
+		Collaborators: collaborators,
+		LastModified:  lastModified,
+	}
+}
+
+// prepareType checks the rules of a smart playlist. Smart playlist has no picked tracks and static playlist has no rules
+func prepareType(in *model.Playlist) error {
+	if in.Type != consts.PlaylistTypeSmart {
+		in.Rules = nil
+		return nil
+	}
+
+	rules := in.Rules
+	if rules == nil {
+		return consts.CodeInvalidSmartRules
+	}
+	if rules.YearFrom > 0 && rules.YearTo > 0 && rules.YearFrom > rules.YearTo {
+		return consts.CodeInvalidSmartRules
+	}
+	if rules.DurationMin > 0 && rules.DurationMax > 0 && rules.DurationMin > rules.DurationMax {
+		return consts.CodeInvalidSmartRules
+	}
+	in.TrackIDs = []string{}
+	return nil
+}
+
+// mergeEntries keeps who added the tracks which are still in the playlist, new tracks are recorded as added by uid
+func mergeEntries(current []model.PlaylistEntry, trackIDs []string, uid string) []model.PlaylistEntry {
+	entryByID := make(map[string]model.PlaylistEntry, len(current))
+	for _, entry := range current {
+		entryByID[entry.TrackID] = entry
+	}
+
+	now := time.Now()
+	entries := make([]model.PlaylistEntry, 0, len(trackIDs))
+	for _, trackID := range trackIDs {
+		entry, ok := entryByID[trackID]
+		if !ok {
+			entry = model.PlaylistEntry{
+				TrackID: trackID,
+				AddedBy: uid,
+				AddedAt: now,
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
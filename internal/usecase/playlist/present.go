@@ -1,16 +1,86 @@
 package playlist_usecase
 
 import (
+	"emvn/consts"
 	"emvn/internal/model"
+	"emvn/pkg/playlistformat"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type PlaylistWithTracks struct {
-	ID          primitive.ObjectID `bson:"_id" json:"id"`
-	Title       string             `json:"title"`
-	Description string             `json:"description"`
-	Genre       string             `json:"genre"`
-	Tracks      []model.MusicTrack `json:"tracks"`
-	CreatedBy   string             `json:"created_by"` // uid of the user who created the playlist
+	ID            primitive.ObjectID           `bson:"_id" json:"id"`
+	Title         string                       `json:"title"`
+	Description   string                       `json:"description"`
+	Genre         string                       `json:"genre"`
+	Visibility    consts.PlaylistVisibility    `json:"visibility"`
+	Type          consts.PlaylistType          `json:"type"`
+	Rules         *model.SmartPlaylistRules    `json:"rules,omitempty"`
+	ForkedFrom    *model.PlaylistFork          `json:"forked_from,omitempty"`
+	ForkCount     int                          `json:"fork_count"`
+	Tracks        []PlaylistTrack              `json:"tracks"`
+	CreatedBy     string                       `json:"created_by"` // uid of the user who created the playlist
+	Collaborators []model.PlaylistCollaborator `json:"collaborators"`
+	// Last change of the playlist or of its tracks. Zero for smart playlists, their tracks come and go with the catalog
+	LastModified time.Time `json:"-"`
+}
+
+// PlaylistTrack is a track of a playlist with the collaborator who added it
+type PlaylistTrack struct {
+	model.MusicTrack
+	AddedBy string    `json:"added_by"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type SyncForkOutput struct {
+	Playlist PlaylistWithTracks `json:"playlist"`
+	Added    []string           `json:"added"`   // track ids added upstream and pulled into the fork
+	Removed  []string           `json:"removed"` // track ids removed upstream and removed from the fork
+}
+
+type ImportPlaylistInput struct {
+	Data       []byte
+	Format     playlistformat.Format
+	Title      string // overrides the title of the file when not empty
+	Genre      string
+	Visibility consts.PlaylistVisibility
+}
+
+// How an imported entry has been matched to a track
+type MatchMethod string
+
+const (
+	MatchByID          MatchMethod = "id"
+	MatchByTitleArtist MatchMethod = "title_artist"
+	MatchByFileName    MatchMethod = "file_name"
+)
+
+type ImportReport struct {
+	Playlist  PlaylistWithTracks `json:"playlist"`
+	Total     int                `json:"total"`
+	Matched   []MatchedEntry     `json:"matched"`
+	Unmatched []UnmatchedEntry   `json:"unmatched"`
+}
+
+type MatchedEntry struct {
+	Index     int         `json:"index"` // position in the imported file, starts at 0
+	TrackID   string      `json:"track_id"`
+	MatchedBy MatchMethod `json:"matched_by"`
+}
+
+type UnmatchedEntry struct {
+	Index int                  `json:"index"`
+	Entry playlistformat.Entry `json:"entry"`
+}
+
+// PlaylistPatch holds the fields to change, nil fields are kept
+type PlaylistPatch struct {
+	Title       *string
+	Description *string
+	Genre       *string
+	TrackIDs    *[]string
+	Visibility  *consts.PlaylistVisibility
+	Type        *consts.PlaylistType
+	Rules       *model.SmartPlaylistRules
 }
@@ -0,0 +1,162 @@
+package user_usecase
+
+import (
+	"context"
+	"emvn/consts"
+	"emvn/internal/model"
+	auth_usecase "emvn/internal/usecase/auth"
+	"emvn/pkg/logger"
+	"emvn/pkg/tracing"
+	"time"
+)
+
+// GetMe returns the user itself, with its profile
+func (u *userUsecase) GetMe(ctx context.Context, uid string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.GetMe")
+	defer span.End()
+	user, err := u.userRepo.GetUserByID(ctx, uid)
+	if err != nil {
+		return model.User{}, err
+	}
+	user.Role = user.GetRole()
+	return user, nil
+}
+
+func (u *userUsecase) UpdateMe(ctx context.Context, uid string, profile model.UserProfile) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.UpdateMe")
+	defer span.End()
+	user, err := u.userRepo.UpdateProfile(ctx, uid, profile)
+	if err != nil {
+		return model.User{}, err
+	}
+	user.Role = user.GetRole()
+	return user, nil
+}
+
+func (u *userUsecase) ListMyPlaylists(ctx context.Context, uid string) ([]model.Playlist, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.ListMyPlaylists")
+	defer span.End()
+	return u.playlistRepo.ListByUser(ctx, uid)
+}
+
+func (u *userUsecase) ListMyTracks(ctx context.Context, uid string) ([]model.MusicTrack, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.ListMyTracks")
+	defer span.End()
+	return u.musicTrackRepo.ListByCreator(ctx, uid)
+}
+
+// DeleteMe deletes the account of uid
+//   - the playlists they created are deleted or transferred to transferTo, according to policy
+//   - they are removed from the collaborators of the other playlists
+//   - the tracks they created are kept without creator
+//   - their sessions and API keys are revoked
+func (u *userUsecase) DeleteMe(ctx context.Context, uid string, in DeleteMeInput) error {
+	ctx, span := tracing.Start(ctx, "userUsecase.DeleteMe")
+	defer span.End()
+	user, err := u.userRepo.GetUserByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	// Users created by single sign on have no password to confirm with
+	if user.Password != "" {
+		if err := u.confirmPassword(ctx, user, in.Password, in.IP); err != nil {
+			return err
+		}
+	}
+
+	switch in.PlaylistPolicy {
+	case consts.PlaylistDeletionPolicyTransfer:
+		if in.TransferTo == "" || in.TransferTo == uid {
+			return consts.CodeInvalidTransferUser
+		}
+		if _, err := u.userRepo.GetUserByID(ctx, in.TransferTo); err != nil {
+			return consts.CodeInvalidTransferUser
+		}
+	case "", consts.PlaylistDeletionPolicyDelete:
+	default:
+		return consts.CodeInvalidRequest
+	}
+
+	playlists, err := u.playlistRepo.ListByUser(ctx, uid)
+	if err != nil {
+		return err
+	}
+	for _, playlist := range playlists {
+		if playlist.CreatedBy != uid {
+			continue
+		}
+		if in.PlaylistPolicy == consts.PlaylistDeletionPolicyTransfer {
+			err = u.playlistRepo.TransferOwnership(ctx, playlist.ID.Hex(), in.TransferTo)
+		} else {
+			err = u.deletePlaylist(ctx, playlist)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := u.playlistRepo.RemoveCollaboratorFromAll(ctx, uid); err != nil {
+		return err
+	}
+
+	if err := u.musicTrackRepo.AnonymizeCreator(ctx, uid); err != nil {
+		return err
+	}
+
+	if err := u.apiKeyRepo.RevokeAllByUser(ctx, uid); err != nil {
+		return err
+	}
+	if err := u.tokenRepo.RevokeUserRefreshTokens(ctx, uid); err != nil {
+		return err
+	}
+	if err := u.revocationStore.RevokeAllBefore(ctx, uid, time.Now()); err != nil {
+		logger.FromContext(ctx).Error("DeleteMe", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+
+	return u.userRepo.DeleteUser(ctx, uid)
+}
+
+// confirmPassword checks the password through the sign in guard, a stolen access token must not allow guessing it faster than signing in
+func (u *userUsecase) confirmPassword(ctx context.Context, user model.User, password string, ip string) error {
+	decision, err := u.loginGuard.Check(ctx, user.Username, ip)
+	if err != nil {
+		logger.FromContext(ctx).Error("confirmPassword", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	if decision.RetryAfter > 0 {
+		if decision.Locked {
+			return auth_usecase.ThrottledError{CustomError: consts.CodeAccountLocked, RetryAfter: decision.RetryAfter}
+		}
+		return auth_usecase.ThrottledError{CustomError: consts.CodeTooManyAttempts, RetryAfter: decision.RetryAfter}
+	}
+
+	match, _, err := u.passwordHasher.Verify(password, user.Password)
+	if err != nil {
+		logger.FromContext(ctx).Error("confirmPassword", "error", err)
+	}
+	if !match {
+		if err := u.loginGuard.Fail(ctx, user.Username, ip); err != nil {
+			logger.FromContext(ctx).Error("confirmPassword", "error", err)
+		}
+		return consts.CodeWrongPassword
+	}
+	if err := u.loginGuard.Succeed(ctx, user.Username); err != nil {
+		logger.FromContext(ctx).Error("confirmPassword", "error", err)
+	}
+	return nil
+}
+
+func (u *userUsecase) deletePlaylist(ctx context.Context, playlist model.Playlist) error {
+	err := u.playlistRepo.Delete(ctx, playlist.ID.Hex())
+	if err != nil {
+		logger.FromContext(ctx).Error("deletePlaylist", "error", err)
+		return consts.CodeInternalError.Wrap(err)
+	}
+	if playlist.ForkedFrom != nil {
+		// Same as deleting the playlist, the count is only informative
+		if err := u.playlistRepo.IncrementForkCount(ctx, playlist.ForkedFrom.PlaylistID, -1); err != nil {
+			logger.FromContext(ctx).Error("deletePlaylist", "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package user_usecase
+
+import "emvn/consts"
+
+type DeleteMeInput struct {
+	// Current password, to confirm the deletion
+	Password       string
+	PlaylistPolicy consts.PlaylistDeletionPolicy
+	// User receiving the playlists with the transfer policy
+	TransferTo string
+	// Client IP, wrong passwords are counted by the sign in guard like a failed sign in
+	IP string
+}
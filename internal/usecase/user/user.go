@@ -0,0 +1,111 @@
+package user_usecase
+
+import (
+	"context"
+	"emvn/consts"
+	"emvn/internal/model"
+	apikey_repository "emvn/internal/repository/api_key"
+	musictrack_repository "emvn/internal/repository/music_track"
+	playlist_repository "emvn/internal/repository/playlist"
+	token_repository "emvn/internal/repository/token"
+	user_repository "emvn/internal/repository/user"
+	"emvn/pkg/logger"
+	"emvn/pkg/loginguard"
+	"emvn/pkg/password"
+	"emvn/pkg/revocation"
+	"emvn/pkg/tracing"
+)
+
+type IUserUsecase interface {
+	ListUsers(ctx context.Context) ([]model.User, error)
+	SetRole(ctx context.Context, id string, role consts.UserRole) (model.User, error)
+	// Unlock the sign in of a user locked after too many failures
+	Unlock(ctx context.Context, id string) (model.User, error)
+
+	// Self service, uid is the authenticated user
+	GetMe(ctx context.Context, uid string) (model.User, error)
+	UpdateMe(ctx context.Context, uid string, profile model.UserProfile) (model.User, error)
+	ListMyPlaylists(ctx context.Context, uid string) ([]model.Playlist, error)
+	ListMyTracks(ctx context.Context, uid string) ([]model.MusicTrack, error)
+	DeleteMe(ctx context.Context, uid string, in DeleteMeInput) error
+}
+
+type userUsecase struct {
+	userRepo        user_repository.IUserRepository
+	playlistRepo    playlist_repository.IPlaylistRepository
+	musicTrackRepo  musictrack_repository.IMusicTrackRepository
+	tokenRepo       token_repository.ITokenRepository
+	apiKeyRepo      apikey_repository.IAPIKeyRepository
+	revocationStore revocation.Store
+	loginGuard      *loginguard.Guard
+	passwordHasher  *password.Hasher
+}
+
+// Singleton pattern
+var localUserUsecase IUserUsecase
+
+func InitUserUsecase(
+	userRepo user_repository.IUserRepository,
+	playlistRepo playlist_repository.IPlaylistRepository,
+	musicTrackRepo musictrack_repository.IMusicTrackRepository,
+	tokenRepo token_repository.ITokenRepository,
+	apiKeyRepo apikey_repository.IAPIKeyRepository,
+	revocationStore revocation.Store,
+	loginGuard *loginguard.Guard,
+	passwordHasher *password.Hasher,
+) {
+	localUserUsecase = &userUsecase{
+		userRepo:        userRepo,
+		playlistRepo:    playlistRepo,
+		musicTrackRepo:  musicTrackRepo,
+		tokenRepo:       tokenRepo,
+		apiKeyRepo:      apiKeyRepo,
+		revocationStore: revocationStore,
+		loginGuard:      loginGuard,
+		passwordHasher:  passwordHasher,
+	}
+}
+
+func UserUsecase() IUserUsecase {
+	return localUserUsecase
+}
+
+// List all users
+func (u *userUsecase) ListUsers(ctx context.Context) ([]model.User, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.ListUsers")
+	defer span.End()
+	users, err := u.userRepo.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].Role = users[i].GetRole()
+	}
+	return users, nil
+}
+
+// Set the role of a user. It is applied to the access token on the next refresh
+func (u *userUsecase) SetRole(ctx context.Context, id string, role consts.UserRole) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.SetRole")
+	defer span.End()
+	if !role.IsValid() {
+		return model.User{}, consts.CodeInvalidRequest
+	}
+	return u.userRepo.UpdateRole(ctx, id, role)
+}
+
+// Unlock the username of a user. The client IP stays locked, an attacker may be using it
+func (u *userUsecase) Unlock(ctx context.Context, id string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.Unlock")
+	defer span.End()
+	user, err := u.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return model.User{}, err
+	}
+	if err := u.loginGuard.Unlock(ctx, user.Username); err != nil {
+		logger.FromContext(ctx).Error("Unlock", "error", err)
+		return model.User{}, consts.CodeInternalError.Wrap(err)
+	}
+	user.Role = user.GetRole()
+	return user, nil
+}
@@ -2,7 +2,10 @@ package middlewares
 
 import (
 	"emvn/consts"
+	apikey_usecase "emvn/internal/usecase/api_key"
 	auth_usecase "emvn/internal/usecase/auth"
+	"emvn/pkg/logger"
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -19,41 +22,64 @@ func getTokenFromBearer(reqToken string) string {
 	return splitToken[1]
 }
 
+// AuthMiddleware accepts either a Bearer access token or an API key in the X-API-Key header
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader(consts.HeaderAPIKey); apiKey != "" {
+			authenticateAPIKey(c, apiKey)
+			return
+		}
+
 		reqTokenHeader := c.GetHeader("Authorization")
 		if reqTokenHeader == "" {
-			c.JSON(consts.CodeTokenRequired.HttpStatus, gin.H{
-				"code":    consts.CodeTokenRequired.Code,
-				"message": consts.CodeTokenRequired.Message,
-			})
-			c.Abort()
+			abortWithError(c, consts.CodeTokenRequired)
 			return
 		}
 		reqToken := getTokenFromBearer(reqTokenHeader)
 		if reqToken == "" {
-			c.JSON(consts.CodeTokenExpired.HttpStatus, gin.H{
-				"code":    consts.CodeTokenExpired.Code,
-				"message": consts.CodeTokenExpired.Message,
-			})
-			c.Abort()
+			abortWithError(c, consts.CodeTokenExpired)
 			return
 		}
 
-		acToken := auth_usecase.AccessToken{}
-		err := acToken.Verify(c.Request.Context(), reqToken)
+		acToken, err := auth_usecase.AuthUsecase().VerifyAccessToken(c.Request.Context(), reqToken)
 		if err != nil {
-			c.JSON(consts.CodeInvalidToken.HttpStatus, gin.H{
-				"code":    consts.CodeInvalidToken.Code,
-				"message": consts.CodeInvalidToken.Message,
-			})
-			c.Abort()
+			var customErr consts.CustomError
+			if !errors.As(err, &customErr) {
+				customErr = consts.CodeInvalidToken.Wrap(err)
+			}
+			abortWithError(c, customErr)
 			return
 		}
 
 		// We can query user from db and set it to context
 		// But for now, we just set the uid to context
-		c.Set(consts.GinAuthUid, acToken.Sub)
+		setUid(c, acToken.Sub)
+		c.Set(consts.GinAuthClaims, acToken)
+		setRole(c, acToken.Role)
 		c.Next()
 	}
 }
+
+// authenticateAPIKey grants the scopes of the key instead of the permissions of a role
+// The key has no role, so routes guarded by RequireRole are not reachable with an API key
+func authenticateAPIKey(c *gin.Context, apiKey string) {
+	key, err := apikey_usecase.APIKeyUsecase().Verify(c.Request.Context(), apiKey)
+	if err != nil {
+		var customErr consts.CustomError
+		if !errors.As(err, &customErr) {
+			customErr = consts.CodeInvalidAPIKey.Wrap(err)
+		}
+		abortWithError(c, customErr)
+		return
+	}
+
+	setUid(c, key.UserID)
+	c.Set(consts.GinAuthPermissions, key.Scopes)
+	c.Next()
+}
+
+// setUid stores the authenticated user in the gin context and adds it to the logger of the request
+func setUid(c *gin.Context, uid string) {
+	c.Set(consts.GinAuthUid, uid)
+	c.Request = c.Request.WithContext(logger.With(c.Request.Context(), logger.KeyUid, uid))
+}
@@ -0,0 +1,141 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"emvn/config"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+// CompressMiddleware compresses the JSON responses with brotli or gzip, the one preferred by Accept-Encoding.
+// Responses smaller than min_size_byte of the compression config and other content types, like the audio streams, are sent as they are
+func CompressMiddleware() gin.HandlerFunc {
+	cfg := config.GetConfig().Compression
+
+	return func(c *gin.Context) {
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: cfg.MinSize}
+		c.Writer = writer
+		defer func() {
+			writer.close()
+			c.Writer = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding returns br or gzip, the one with the highest q-value in Accept-Encoding, br on a tie.
+// It returns an empty string when neither is accepted
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		qualities[strings.ToLower(strings.TrimSpace(name))] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range []string{encodingBrotli, encodingGzip} {
+		quality, ok := qualities[encoding]
+		if !ok {
+			// * applies to the encodings not listed
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// compressWriter decides on the first write whether the response is compressed, the headers are known by then
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	decided  bool
+	encoder  io.WriteCloser
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decide(len(data))
+	}
+	if w.encoder == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.encoder.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Flush() {
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) decide(size int) {
+	w.decided = true
+	header := w.Header()
+	if size < w.minSize || header.Get("Content-Encoding") != "" || !isJSON(header.Get("Content-Type")) {
+		return
+	}
+
+	header.Set("Content-Encoding", w.encoding)
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	switch w.encoding {
+	case encodingBrotli:
+		w.encoder = brotli.NewWriter(w.ResponseWriter)
+	case encodingGzip:
+		w.encoder = gzip.NewWriter(w.ResponseWriter)
+	}
+}
+
+func (w *compressWriter) close() {
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+	}
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
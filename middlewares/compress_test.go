@@ -0,0 +1,53 @@
+package middlewares
+
+import "testing"
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{acceptEncoding: "", want: ""},
+		{acceptEncoding: "identity", want: ""},
+		{acceptEncoding: "gzip", want: encodingGzip},
+		{acceptEncoding: "br", want: encodingBrotli},
+		{acceptEncoding: "gzip, deflate, br", want: encodingBrotli},
+		{acceptEncoding: "GZIP, BR", want: encodingBrotli},
+		{acceptEncoding: "br;q=0.5, gzip;q=0.8", want: encodingGzip},
+		{acceptEncoding: "br; q=0.8 , gzip;q=0.8", want: encodingBrotli},
+		{acceptEncoding: "br;q=0, gzip", want: encodingGzip},
+		{acceptEncoding: "br;q=0, gzip;q=0", want: ""},
+		{acceptEncoding: "*", want: encodingBrotli},
+		{acceptEncoding: "*;q=0.5, br;q=0.1", want: encodingGzip},
+		{acceptEncoding: "gzip;q=0.2, *;q=0", want: encodingGzip},
+		{acceptEncoding: "br;q=abc, gzip;q=0.1", want: encodingGzip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			if got := negotiateEncoding(tt.acceptEncoding); got != tt.want {
+				t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.acceptEncoding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsJSON(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{contentType: "application/json", want: true},
+		{contentType: "application/json; charset=utf-8", want: true},
+		{contentType: "application/problem+json", want: true},
+		{contentType: "audio/mpeg"},
+		{contentType: "text/plain; charset=utf-8"},
+		{contentType: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if got := isJSON(tt.contentType); got != tt.want {
+				t.Errorf("isJSON(%q) = %v, want %v", tt.contentType, got, tt.want)
+			}
+		})
+	}
+}
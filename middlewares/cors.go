@@ -1,20 +1,220 @@
 package middlewares
 
-import "github.com/gin-gonic/gin"
+import (
+	"emvn/config"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
-// CORS allows Cross-origin resource sharing.
-// Allow all origins, all methods, all headers, and credentials.
-// In production, you should restrict the origin to your domain though a environment variable.
-// For example, you can use the following code:
-// c.Header("Access-Control-Allow-Origin", config.GetConfig().Frontend.Domain)
+	"github.com/gin-gonic/gin"
+)
+
+// Any origin with credentials would let every site make authenticated requests, the policy is refused when it loads
+var errCORSAnyOriginCredentials = errors.New("allowed_origins * can not be used with allow_credentials")
+
+// Used when the policy does not list them
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", "X-API-Key", "X-Request-ID", "If-None-Match", "If-Modified-Since", "Idempotency-Key"}
+)
+
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          map[string]bool
+	wildcards        [][2]string // prefix and suffix around the * of https://*.example.com
+	methods          []string
+	allowMethods     string
+	anyHeader        bool
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+type corsGroup struct {
+	prefix string
+	policy corsPolicy
+}
+
+// CORSMiddleware allows Cross-origin resource sharing with the policy of the cors config
+// Preflight requests match no route, so the policy of a route group is found by its path prefix, not by a group middleware.
+// Allowed origins are reflected, any origin is answered with * and never with credentials
 func CORSMiddleware() gin.HandlerFunc {
+	cfg := config.GetConfig().CORS
+	defaultPolicy, err := newCORSPolicy(cfg.CORSPolicy)
+	if err != nil {
+		log.Fatalf("cors: %s\n", err)
+	}
+	groups := make([]corsGroup, 0, len(cfg.Groups))
+	for prefix, groupCfg := range cfg.Groups {
+		policy, err := newCORSPolicy(mergeCORSPolicy(cfg.CORSPolicy, groupCfg))
+		if err != nil {
+			log.Fatalf("cors.groups.%s: %s\n", prefix, err)
+		}
+		groups = append(groups, corsGroup{prefix: prefix, policy: policy})
+	}
+	// The longest prefix wins
+	sort.Slice(groups, func(i, j int) bool { return len(groups[i].prefix) > len(groups[j].prefix) })
+
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Length, Content-Range")
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Max-Age", "600")
-		c.Next()
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		policy := defaultPolicy
+		for _, group := range groups {
+			if hasPathPrefix(c.Request.URL.Path, group.prefix) {
+				policy = group.policy
+				break
+			}
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !policy.allowOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if policy.anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials && !policy.anyOrigin {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if policy.exposeHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", policy.exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		// Preflight, answer it without running the route
+		if !slices.Contains(policy.methods, strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))) {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Header("Access-Control-Allow-Methods", policy.allowMethods)
+		allowHeaders := policy.allowHeaders
+		if policy.anyHeader {
+			allowHeaders = c.GetHeader("Access-Control-Request-Headers")
+		}
+		if allowHeaders != "" {
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+		}
+		if policy.maxAge != "" {
+			c.Header("Access-Control-Max-Age", policy.maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func newCORSPolicy(cfg config.CORSPolicy) (corsPolicy, error) {
+	policy := corsPolicy{origins: map[string]bool{}}
+	var origins []string
+	// An entry may hold several origins separated by commas, e.g. from an environment variable
+	for _, entry := range cfg.AllowedOrigins {
+		origins = append(origins, strings.Split(entry, ",")...)
+	}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "*":
+			policy.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			policy.wildcards = append(policy.wildcards, [2]string{prefix, suffix})
+		case origin != "":
+			policy.origins[origin] = true
+		}
+	}
+
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
 	}
+	for _, method := range methods {
+		policy.methods = append(policy.methods, strings.ToUpper(strings.TrimSpace(method)))
+	}
+	policy.allowMethods = strings.Join(policy.methods, ", ")
+
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	policy.anyHeader = slices.Contains(headers, "*")
+	policy.allowHeaders = strings.Join(headers, ", ")
+	policy.exposeHeaders = strings.Join(cfg.ExposedHeaders, ", ")
+
+	policy.allowCredentials = cfg.AllowCredentials != nil && *cfg.AllowCredentials
+	if cfg.MaxAgeSecond > 0 {
+		policy.maxAge = strconv.Itoa(cfg.MaxAgeSecond)
+	}
+	if policy.anyOrigin && policy.allowCredentials {
+		return corsPolicy{}, errCORSAnyOriginCredentials
+	}
+	return policy, nil
+}
+
+// mergeCORSPolicy fills what the group does not set with the default policy
+func mergeCORSPolicy(base config.CORSPolicy, group config.CORSPolicy) config.CORSPolicy {
+	if len(group.AllowedOrigins) == 0 {
+		group.AllowedOrigins = base.AllowedOrigins
+	}
+	if len(group.AllowedMethods) == 0 {
+		group.AllowedMethods = base.AllowedMethods
+	}
+	if len(group.AllowedHeaders) == 0 {
+		group.AllowedHeaders = base.AllowedHeaders
+	}
+	if len(group.ExposedHeaders) == 0 {
+		group.ExposedHeaders = base.ExposedHeaders
+	}
+	if group.AllowCredentials == nil {
+		group.AllowCredentials = base.AllowCredentials
+	}
+	if group.MaxAgeSecond == 0 {
+		group.MaxAgeSecond = base.MaxAgeSecond
+	}
+	return group
+}
+
+func (p corsPolicy) allowOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, wildcard := range p.wildcards {
+		prefix, suffix := wildcard[0], wildcard[1]
+		if len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			// Only subdomains, the * can not reach another host or port
+			subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+			if !strings.ContainsAny(subdomain, "/:@") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasPathPrefix(path string, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
 }
@@ -0,0 +1,196 @@
+package middlewares
+
+import (
+	"emvn/config"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCORSAllowOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{name: "listed origin", allowed: []string{"https://app.example.com"}, origin: "https://app.example.com", want: true},
+		{name: "case insensitive", allowed: []string{"https://App.Example.com"}, origin: "HTTPS://app.example.COM", want: true},
+		{name: "comma separated entry", allowed: []string{"https://a.example.com, https://b.example.com"}, origin: "https://b.example.com", want: true},
+		{name: "other origin", allowed: []string{"https://app.example.com"}, origin: "https://evil.example.com"},
+		{name: "other scheme", allowed: []string{"https://app.example.com"}, origin: "http://app.example.com"},
+		{name: "other port", allowed: []string{"https://app.example.com"}, origin: "https://app.example.com:8443"},
+		{name: "no origin allowed", origin: "https://app.example.com"},
+		{name: "any origin", allowed: []string{"*"}, origin: "https://anything.example", want: true},
+		{name: "wildcard subdomain", allowed: []string{"https://*.example.com"}, origin: "https://app.example.com", want: true},
+		{name: "wildcard nested subdomain", allowed: []string{"https://*.example.com"}, origin: "https://a.b.example.com", want: true},
+		{name: "wildcard needs a subdomain", allowed: []string{"https://*.example.com"}, origin: "https://.example.com"},
+		{name: "wildcard does not match the apex", allowed: []string{"https://*.example.com"}, origin: "https://example.com"},
+		{name: "wildcard other domain", allowed: []string{"https://*.example.com"}, origin: "https://app.example.com.evil.io"},
+		{name: "wildcard suffix of another domain", allowed: []string{"https://*.example.com"}, origin: "https://evilexample.com"},
+		{name: "wildcard other scheme", allowed: []string{"https://*.example.com"}, origin: "http://app.example.com"},
+		{name: "wildcard can not reach another host", allowed: []string{"https://*.example.com"}, origin: "https://evil.io/.example.com"},
+		{name: "wildcard can not hold credentials", allowed: []string{"https://*.example.com"}, origin: "https://user@evil.io.example.com"},
+		{name: "wildcard can not hold a port", allowed: []string{"https://*.example.com"}, origin: "https://evil.io:1.example.com"},
+		{name: "wildcard with a port", allowed: []string{"http://*.localhost:3000"}, origin: "http://app.localhost:3000", want: true},
+		{name: "wildcard with another port", allowed: []string{"http://*.localhost:3000"}, origin: "http://app.localhost:4000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := newCORSPolicy(config.CORSPolicy{AllowedOrigins: tt.allowed})
+			if err != nil {
+				t.Fatalf("newCORSPolicy() error = %v", err)
+			}
+			if got := policy.allowOrigin(tt.origin); got != tt.want {
+				t.Errorf("allowOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCORSPolicy(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name    string
+		cfg     config.CORSPolicy
+		want    corsPolicy
+		wantErr error
+	}{
+		{
+			name: "defaults",
+			cfg:  config.CORSPolicy{},
+			want: corsPolicy{
+				origins:      map[string]bool{},
+				methods:      defaultCORSMethods,
+				allowMethods: "GET, POST, PUT, PATCH, DELETE",
+				allowHeaders: "Content-Type, Authorization, X-API-Key, X-Request-ID, If-None-Match, If-Modified-Since, Idempotency-Key",
+			},
+		},
+		{
+			name: "configured",
+			cfg: config.CORSPolicy{
+				AllowedOrigins:   []string{"https://app.example.com"},
+				AllowedMethods:   []string{"get", " post "},
+				AllowedHeaders:   []string{"*"},
+				ExposedHeaders:   []string{"ETag", "X-Request-ID"},
+				AllowCredentials: &yes,
+				MaxAgeSecond:     600,
+			},
+			want: corsPolicy{
+				origins:          map[string]bool{"https://app.example.com": true},
+				methods:          []string{"GET", "POST"},
+				allowMethods:     "GET, POST",
+				anyHeader:        true,
+				allowHeaders:     "*",
+				exposeHeaders:    "ETag, X-Request-ID",
+				allowCredentials: true,
+				maxAge:           "600",
+			},
+		},
+		{
+			name:    "any origin with credentials",
+			cfg:     config.CORSPolicy{AllowedOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: &yes},
+			wantErr: errCORSAnyOriginCredentials,
+		},
+		{
+			name: "any origin without credentials",
+			cfg:  config.CORSPolicy{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}, AllowedHeaders: []string{"Authorization"}, AllowCredentials: &no},
+			want: corsPolicy{
+				anyOrigin:    true,
+				origins:      map[string]bool{},
+				methods:      []string{"GET"},
+				allowMethods: "GET",
+				allowHeaders: "Authorization",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newCORSPolicy(tt.cfg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("newCORSPolicy() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newCORSPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeCORSPolicy(t *testing.T) {
+	yes, no := true, false
+	base := config.CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{"GET"},
+		AllowedHeaders:   []string{"Authorization"},
+		ExposedHeaders:   []string{"ETag"},
+		AllowCredentials: &yes,
+		MaxAgeSecond:     600,
+	}
+	tests := []struct {
+		name  string
+		group config.CORSPolicy
+		want  config.CORSPolicy
+	}{
+		{name: "empty group takes the default policy", group: config.CORSPolicy{}, want: base},
+		{
+			name: "group overrides",
+			group: config.CORSPolicy{
+				AllowedOrigins:   []string{"*"},
+				AllowedMethods:   []string{"POST"},
+				AllowedHeaders:   []string{"*"},
+				ExposedHeaders:   []string{"Location"},
+				AllowCredentials: &no,
+				MaxAgeSecond:     60,
+			},
+			want: config.CORSPolicy{
+				AllowedOrigins:   []string{"*"},
+				AllowedMethods:   []string{"POST"},
+				AllowedHeaders:   []string{"*"},
+				ExposedHeaders:   []string{"Location"},
+				AllowCredentials: &no,
+				MaxAgeSecond:     60,
+			},
+		},
+		{
+			name:  "credentials turned off by the group",
+			group: config.CORSPolicy{AllowCredentials: &no},
+			want: config.CORSPolicy{
+				AllowedOrigins:   base.AllowedOrigins,
+				AllowedMethods:   base.AllowedMethods,
+				AllowedHeaders:   base.AllowedHeaders,
+				ExposedHeaders:   base.ExposedHeaders,
+				AllowCredentials: &no,
+				MaxAgeSecond:     base.MaxAgeSecond,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeCORSPolicy(base, tt.group); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeCORSPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasPathPrefix(t *testing.T) {
+	tests := []struct {
+		path   string
+		prefix string
+		want   bool
+	}{
+		{path: "/v1/public", prefix: "/v1/public", want: true},
+		{path: "/v1/public/playlists", prefix: "/v1/public", want: true},
+		{path: "/v1/public/playlists", prefix: "/v1/public/", want: true},
+		{path: "/v1/publicity", prefix: "/v1/public"},
+		{path: "/v1", prefix: "/v1/public"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.prefix, func(t *testing.T) {
+			if got := hasPathPrefix(tt.path, tt.prefix); got != tt.want {
+				t.Errorf("hasPathPrefix(%q, %q) = %v, want %v", tt.path, tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"emvn/config"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControlMiddleware sets the Cache-Control of the http_cache config on the GET routes listed there.
+// Routes are matched by their template, e.g. /music_track/get/:id. ResponseMiddleware replaces it with no-store on error
+func CacheControlMiddleware() gin.HandlerFunc {
+	cacheControl := config.GetConfig().HTTPCache.CacheControl
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		if value, ok := cacheControl[c.FullPath()]; ok && value != "" {
+			c.Header("Cache-Control", value)
+		}
+		c.Next()
+	}
+}
+
+// writeValidators sets the ETag and Last-Modified of the response data, and returns true when
+// the conditional headers of the request show that the copy of the client is still fresh
+// The ETag is weak, it is computed on the JSON of the data, not on the bytes sent which may be compressed
+func writeValidators(c *gin.Context, data interface{}, lastModified time.Time) bool {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return false
+	}
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-Modified-Since is ignored when If-None-Match is sent, RFC 9110 section 13.2.2
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		return etagMatch(ifNoneMatch, etag)
+	}
+	if ifModifiedSince := c.GetHeader("If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		// Last-Modified has a precision of one second
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// etagMatch does the weak comparison of If-None-Match, the W/ prefix is ignored
+func etagMatch(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestETagMatch(t *testing.T) {
+	const etag = `W/"0123abcd"`
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{ifNoneMatch: `W/"0123abcd"`, want: true},
+		{ifNoneMatch: `"0123abcd"`, want: true},
+		{ifNoneMatch: `"other", W/"0123abcd"`, want: true},
+		{ifNoneMatch: ` "other" ,"0123abcd" `, want: true},
+		{ifNoneMatch: `*`, want: true},
+		{ifNoneMatch: `"other"`},
+		{ifNoneMatch: `W/"0123abc"`},
+		{ifNoneMatch: `0123abcd`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ifNoneMatch, func(t *testing.T) {
+			if got := etagMatch(tt.ifNoneMatch, etag); got != tt.want {
+				t.Errorf("etagMatch(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteValidators(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	data := map[string]string{"id": "1"}
+	lastModified := time.Date(2026, time.October, 19, 12, 0, 0, 500*int(time.Millisecond), time.UTC)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	writeValidators(c, data, lastModified)
+	etag := recorder.Header().Get("ETag")
+	if len(etag) != len(`W/""`)+32 || etag[:3] != `W/"` {
+		t.Fatalf("ETag = %q, want a weak etag", etag)
+	}
+	if got := recorder.Header().Get("Last-Modified"); got != "Mon, 19 Oct 2026 12:00:00 GMT" {
+		t.Fatalf("Last-Modified = %q, want Mon, 19 Oct 2026 12:00:00 GMT", got)
+	}
+
+	tests := []struct {
+		name         string
+		data         interface{}
+		lastModified time.Time
+		header       http.Header
+		want         bool
+	}{
+		{name: "no conditional header", data: data, lastModified: lastModified},
+		{name: "same etag", data: data, header: http.Header{"If-None-Match": {etag}}, want: true},
+		{name: "other data", data: map[string]string{"id": "2"}, header: http.Header{"If-None-Match": {etag}}},
+		{name: "not modified since", data: data, lastModified: lastModified, header: http.Header{"If-Modified-Since": {lastModified.Format(http.TimeFormat)}}, want: true},
+		{name: "modified since", data: data, lastModified: lastModified, header: http.Header{"If-Modified-Since": {lastModified.Add(-time.Second).Format(http.TimeFormat)}}},
+		{name: "invalid date", data: data, lastModified: lastModified, header: http.Header{"If-Modified-Since": {"yesterday"}}},
+		{name: "no last modified", data: data, header: http.Header{"If-Modified-Since": {lastModified.Format(http.TimeFormat)}}},
+		{
+			name:         "if none match wins over if modified since",
+			data:         data,
+			lastModified: lastModified,
+			header:       http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {lastModified.Format(http.TimeFormat)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.Header = tt.header
+			if got := writeValidators(c, tt.data, tt.lastModified); got != tt.want {
+				t.Errorf("writeValidators() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"emvn/config"
+	"emvn/consts"
+	"emvn/pkg/idempotency"
+	"emvn/pkg/logger"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultIdempotencyTTL         = 24 * time.Hour
+	defaultIdempotencyMaxBodyByte = 64 << 20
+	// How long the first request holds its key. A crashed request frees it after this
+	idempotencyLockTimeout = 5 * time.Minute
+)
+
+// Headers of the first response sent again with the replays
+var replayedHeaders = []string{"Location"}
+
+var idempotencyKeyPattern = regexp.MustCompile(`^[\x21-\x7E]{1,255}$`)
+
+var idempotencyStore idempotency.Store
+
+// InitIdempotency sets the store of the keys. Without a store, Idempotency-Key is ignored
+func InitIdempotency(store idempotency.Store) {
+	idempotencyStore = store
+}
+
+// IdempotencyMiddleware makes a create or an upload safe to retry with the Idempotency-Key header.
+// The first successful response of a key is stored for ttl_hour of the idempotency config, the retries with the same key
+// get it again without running the handler. A retry with another body gets a conflict. Keys are per user, use it after AuthMiddleware.
+// Errors are not stored, the request can be retried with the same key
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(consts.HeaderIdempotencyKey)
+		// Reads are idempotent already
+		if key == "" || idempotencyStore == nil || isSafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if !idempotencyKeyPattern.MatchString(key) {
+			abortWithError(c, consts.CodeInvalidIdempotency)
+			return
+		}
+
+		// The body is buffered to be fingerprinted and read again by the handler, a larger one is refused before reading it
+		maxBodyByte := idempotencyMaxBodyByte()
+		if c.Request.ContentLength > maxBodyByte {
+			abortWithError(c, consts.CodeIdempotencyTooLarge)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyByte))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				abortWithError(c, consts.CodeIdempotencyTooLarge)
+				return
+			}
+			abortWithError(c, consts.CodeInvalidRequest.Wrap(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		owner := "ip:" + c.ClientIP()
+		if uid := c.GetString(consts.GinAuthUid); uid != "" {
+			owner = "uid:" + uid
+		}
+		storeKey := owner + ":" + c.Request.Method + ":" + c.FullPath() + ":" + key
+		fingerprint := requestFingerprint(c.Request, body)
+
+		ctx := c.Request.Context()
+		record, started, err := idempotencyStore.Start(ctx, storeKey, fingerprint, time.Now().Add(idempotencyLockTimeout))
+		if err != nil {
+			// Like without the header, rather than turning the creates down when the store is down
+			logger.FromContext(ctx).Error("IdempotencyMiddleware", "error", err)
+			c.Next()
+			return
+		}
+		if !started {
+			replayIdempotent(c, record, fingerprint)
+			return
+		}
+
+		c.Next()
+
+		// The response is stored even when the client is gone, that is when it retries
+		ctx = context.WithoutCancel(ctx)
+		data, ok := c.Get(consts.GinResponseKey)
+		if _, failed := c.Get(consts.GinErrorKey); failed || !ok {
+			if err := idempotencyStore.Release(ctx, storeKey); err != nil {
+				logger.FromContext(ctx).Error("IdempotencyMiddleware", "error", err)
+			}
+			return
+		}
+		content, err := json.Marshal(data)
+		if err != nil {
+			logger.FromContext(ctx).Error("IdempotencyMiddleware", "error", err)
+			_ = idempotencyStore.Release(ctx, storeKey)
+			return
+		}
+		response := idempotency.Response{Status: c.GetInt(consts.GinStatusKey), Header: map[string]string{}, Body: content}
+		for _, name := range replayedHeaders {
+			if value := c.Writer.Header().Get(name); value != "" {
+				response.Header[name] = value
+			}
+		}
+		if err := idempotencyStore.Complete(ctx, storeKey, response, time.Now().Add(idempotencyTTL())); err != nil {
+			logger.FromContext(ctx).Error("IdempotencyMiddleware", "error", err)
+		}
+	}
+}
+
+// replayIdempotent answers a retry with the response of the first request, ResponseMiddleware wraps it with the request id of the retry
+func replayIdempotent(c *gin.Context, record idempotency.Record, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		abortWithError(c, consts.CodeIdempotencyReused)
+		return
+	}
+	if record.Response == nil {
+		abortWithError(c, consts.CodeIdempotencyPending)
+		return
+	}
+
+	for name, value := range record.Response.Header {
+		c.Header(name, value)
+	}
+	c.Header(consts.HeaderIdempotentReplayed, "true")
+	if record.Response.Status != 0 {
+		c.Set(consts.GinStatusKey, record.Response.Status)
+	}
+	c.Set(consts.GinResponseKey, json.RawMessage(record.Response.Body))
+	c.Abort()
+}
+
+// requestFingerprint hashes the path, the query and the body of the request.
+// The boundary of a multipart body is left out, clients usually pick a new one when they retry
+func requestFingerprint(r *http.Request, body []byte) string {
+	if mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil &&
+		strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		body = bytes.ReplaceAll(body, []byte(params["boundary"]), nil)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(r.URL.RequestURI()))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func idempotencyMaxBodyByte() int64 {
+	if maxBodyByte := config.GetConfig().Idempotency.MaxBodyByte; maxBodyByte > 0 {
+		return maxBodyByte
+	}
+	return defaultIdempotencyMaxBodyByte
+}
+
+func idempotencyTTL() time.Duration {
+	if hours := config.GetConfig().Idempotency.TTLHour; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultIdempotencyTTL
+}
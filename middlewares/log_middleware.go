@@ -2,8 +2,8 @@ package middlewares
 
 import (
 	"emvn/consts"
-	"fmt"
-	"log"
+	"emvn/pkg/logger"
+	"log/slog"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,30 +11,32 @@ import (
 
 // LogMiddleware is a middleware to log the request
 // When error occurs, we set the error to the gin context, so that we can log the error in this middleware
+// The logger of the request context carries the request id, and the uid once AuthMiddleware has run
 func LogMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		t := time.Now()
 		c.Next()
 
-		errStr := ""
+		statusCode := c.Writer.Status()
+		attrs := []any{
+			"status", statusCode,
+			"latency", time.Since(t),
+			"ip", c.ClientIP(),
+			"user_agent", c.GetHeader("User-Agent"),
+			"method", c.Request.Method,
+			"uri", c.Request.RequestURI,
+		}
 		if err, ok := c.Get(consts.GinErrorKey); ok {
-			errStr = " | " + err.(consts.CustomError).Error()
+			attrs = append(attrs, "error", err)
 		}
 
-		latency := time.Since(t)
-
-		statusCode := c.Writer.Status()
-		stdOut := fmt.Sprintf("[GF] %v | %3d | %v | %v | %v | %v %#v %v\n",
-			t.Format("2006/01/02 - 15:04:05 -07"),
-			statusCode,
-			latency,
-			c.ClientIP(),
-			c.GetHeader("User-Agent"),
-			c.Request.Method,
-			c.Request.RequestURI,
-			errStr,
-		)
-
-		log.Println(stdOut)
+		level := slog.LevelInfo
+		switch {
+		case statusCode >= 500:
+			level = slog.LevelError
+		case statusCode >= 400:
+			level = slog.LevelWarn
+		}
+		logger.FromContext(c.Request.Context()).Log(c.Request.Context(), level, "request", attrs...)
 	}
 }
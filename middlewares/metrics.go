@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"emvn/consts"
+	"emvn/pkg/metrics"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Methods with their own label, any other method is counted as other
+var metricsMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// MetricsMiddleware records the count and latency of the requests by route template
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// Unknown paths share one label, otherwise every scanned path becomes a series
+			route = "unmatched"
+		}
+		// Like the paths, the client picks the method and could create as many series as it wants
+		method := c.Request.Method
+		if !metricsMethods[method] {
+			method = "other"
+		}
+		metrics.ObserveHTTPRequest(method, route, c.Writer.Status(), time.Since(t))
+	}
+}
+
+// MetricsTokenMiddleware only lets the scrapers sending the bearer token through
+func MetricsTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqToken := getTokenFromBearer(c.GetHeader("Authorization"))
+		if reqToken == "" || subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) != 1 {
+			abortWithError(c, consts.CodeInvalidToken)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"emvn/config"
+	"emvn/consts"
+	"emvn/pkg/logger"
+	"emvn/pkg/ratelimit"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var rateLimitStore ratelimit.Store
+
+// InitRateLimiter sets the store of the buckets. Without a store, requests are not limited
+func InitRateLimiter(store ratelimit.Store) {
+	rateLimitStore = store
+}
+
+// RateLimitMiddleware limits the requests of a route group with a token bucket per user, or per client IP when not signed in.
+// Use it after AuthMiddleware to limit per user. The limit of the group comes from the rate_limit config
+func RateLimitMiddleware(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.GetConfig().RateLimit
+		if !cfg.Enabled || rateLimitStore == nil {
+			c.Next()
+			return
+		}
+
+		rule, ok := cfg.Groups[group]
+		if !ok || rule.RequestsPerMinute <= 0 {
+			rule = cfg.Default
+		}
+		if rule.RequestsPerMinute <= 0 {
+			c.Next()
+			return
+		}
+		burst := rule.Burst
+		if burst <= 0 {
+			burst = rule.RequestsPerMinute
+		}
+
+		// The forwarded IP is only used from server.trusted_proxies, a client can not get a new bucket per request
+		key := group + ":ip:" + c.ClientIP()
+		if uid := c.GetString(consts.GinAuthUid); uid != "" {
+			key = group + ":uid:" + uid
+		}
+
+		result, err := rateLimitStore.Take(c.Request.Context(), key, ratelimit.PerMinute(rule.RequestsPerMinute, burst))
+		if err != nil {
+			// Do not turn the API down when the store is down
+			logger.FromContext(c.Request.Context()).Error("RateLimitMiddleware", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(burst))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+			abortWithError(c, consts.CodeRateLimited)
+			return
+		}
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,50 @@
+package middlewares
+
+import (
+	"emvn/consts"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setRole stores the role of the authenticated user and its permissions in the gin context
+// Tokens issued before roles were introduced have no role, they are listeners
+func setRole(c *gin.Context, role consts.UserRole) {
+	if role == "" {
+		role = consts.UserRoleListener
+	}
+	c.Set(consts.GinAuthRole, role)
+	c.Set(consts.GinAuthPermissions, role.Permissions())
+}
+
+// RequireRole only lets users with one of the roles through. Must be used after AuthMiddleware
+func RequireRole(roles ...consts.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get(consts.GinAuthRole)
+		userRole, _ := role.(consts.UserRole)
+		if !slices.Contains(roles, userRole) {
+			abortPermissionDenied(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission only lets users granted all the permissions through. Must be used after AuthMiddleware
+func RequirePermission(permissions ...consts.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, _ := c.Get(consts.GinAuthPermissions)
+		grantedPermissions, _ := granted.([]consts.Permission)
+		for _, permission := range permissions {
+			if !slices.Contains(grantedPermissions, permission) {
+				abortPermissionDenied(c)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func abortPermissionDenied(c *gin.Context) {
+	abortWithError(c, consts.CodePermissionDenied)
+}
@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"emvn/consts"
+	"emvn/pkg/logger"
+	"emvn/utility"
+	"log/slog"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Request ids sent by clients are only kept when they are short and safe to log
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIDMiddleware keeps the X-Request-ID of the client or generates one, and sends it back.
+// The request context gets a logger with the request id, see logger.FromContext. Must be the first middleware
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(consts.HeaderRequestID)
+		if !requestIDPattern.MatchString(requestID) {
+			var err error
+			requestID, err = utility.GenerateRandomToken(16)
+			if err != nil {
+				slog.Error("RequestIDMiddleware", "error", err)
+			}
+		}
+
+		c.Set(consts.GinRequestID, requestID)
+		c.Header(consts.HeaderRequestID, requestID)
+		c.Request = c.Request.WithContext(logger.With(c.Request.Context(), logger.KeyRequestID, requestID))
+		c.Next()
+	}
+}
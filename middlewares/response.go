@@ -2,37 +2,83 @@ package middlewares
 
 import (
 	"emvn/consts"
+	"emvn/pkg/apperror"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Response is the envelope of every response, code is 0 on success
 type Response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data"`
+	RequestID string      `json:"request_id,omitempty"`
 }
 
 func ResponseMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
-		if err, ok := c.Get(consts.GinErrorKey); ok {
-			err := err.(consts.CustomError)
+		if value, ok := c.Get(consts.GinErrorKey); ok {
+			err, _ := value.(error)
+			appErr := apperror.From(err)
+			message := appErr.Message
 			if additionalErr, ok := c.Get(consts.GinDetailErrorKey); ok {
-				err.Message = err.Message + " | " + additionalErr.(error).Error()
+				if additionalErr, ok := additionalErr.(error); ok {
+					message = message + " | " + additionalErr.Error()
+				}
 			}
-			c.JSON(err.HttpStatus, err.Detail())
+			// An error must not be cached like the response of the route
+			if c.Writer.Header().Get("Cache-Control") != "" {
+				c.Header("Cache-Control", "no-store")
+			}
+			// A handler may set details of the error as data
+			data, _ := c.Get(consts.GinResponseKey)
+			c.JSON(appErr.HttpStatus, Response{
+				Code:      appErr.Code,
+				Message:   message,
+				Data:      data,
+				RequestID: c.GetString(consts.GinRequestID),
+			})
 			c.Abort()
 			return
 		}
 
 		if data, ok := c.Get(consts.GinResponseKey); ok {
-			c.JSON(http.StatusOK, Response{
-				Code:    0,
-				Message: "Success",
-				Data:    data,
+			if lastModified, ok := c.Get(consts.GinLastModifiedKey); ok && isSafeMethod(c.Request.Method) {
+				lastModified, _ := lastModified.(time.Time)
+				if writeValidators(c, data, lastModified) {
+					c.Status(http.StatusNotModified)
+					return
+				}
+			}
+			status := http.StatusOK
+			if value := c.GetInt(consts.GinStatusKey); value != 0 {
+				status = value
+			}
+			if status == http.StatusNoContent {
+				c.Status(status)
+				return
+			}
+			c.JSON(status, Response{
+				Code:      0,
+				Message:   "Success",
+				Data:      data,
+				RequestID: c.GetString(consts.GinRequestID),
 			})
 		}
 	}
 }
+
+// abortWithError stops the request, ResponseMiddleware sends the error and LogMiddleware logs it
+// Middlewares use it instead of writing the response themselves, so every error has the same envelope
+func abortWithError(c *gin.Context, err error) {
+	c.Set(consts.GinErrorKey, err)
+	c.Abort()
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"emvn/consts"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RESTMiddleware gives the /v1 routes their status codes: 201 with the Location of the resource created,
+// from the id the handler sets with GinResourceIDKey, and 204 without body on delete
+func RESTMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if _, failed := c.Get(consts.GinErrorKey); failed {
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodPost:
+			if id := c.GetString(consts.GinResourceIDKey); id != "" {
+				c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+id)
+				c.Set(consts.GinStatusKey, http.StatusCreated)
+			}
+		case http.MethodDelete:
+			c.Set(consts.GinStatusKey, http.StatusNoContent)
+		}
+	}
+}
+
+// DeprecationMiddleware marks the legacy routes replaced by a /v1 route, successors maps their route template
+// to the one of the /v1 route, e.g. /music_track/get/:id to /v1/tracks/:id. Other routes of the group are left as they are.
+// It sends the Link to the successor, Deprecation (RFC 9745) when deprecatedAt is set and Sunset (RFC 8594) when sunsetAt is set
+func DeprecationMiddleware(successors map[string]string, deprecatedAt time.Time, sunsetAt time.Time) gin.HandlerFunc {
+	deprecation := ""
+	if !deprecatedAt.IsZero() {
+		deprecation = "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	}
+
+	return func(c *gin.Context) {
+		successor, ok := successors[c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+		for _, param := range c.Params {
+			successor = strings.Replace(successor, ":"+param.Key, param.Value, 1)
+		}
+
+		if deprecation != "" {
+			c.Header("Deprecation", deprecation)
+		}
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		if !sunsetAt.IsZero() {
+			c.Header("Sunset", sunsetAt.UTC().Format(http.TimeFormat))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"emvn/consts"
+	"emvn/pkg/logger"
+	"emvn/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware starts the span of the request, child of the W3C traceparent of the caller when there is one
+// The trace id is added to the logger of the request. Must be after RequestIDMiddleware
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method
+		}
+		ctx, span := tracing.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			ctx = logger.With(ctx, "trace_id", spanContext.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if value, ok := c.Get(consts.GinErrorKey); ok {
+			if err, ok := value.(error); ok {
+				tracing.RecordError(span, err)
+			}
+		}
+		if status >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type detail struct {
+	Code    int    `json:"code"` // Error code for the client to handle
+	Message string `json:"message"`
+}
+
+// Error has a stable code for the client to handle and the HTTP status of the response
+// It can wrap its cause, e.g. a database error. The cause is logged, it is never sent to the client
+type Error struct {
+	detail
+	HttpStatus int `json:"http"` // HTTP status code
+	cause      error
+}
+
+// Every code is registered once, a code is part of the API so it must never change or be reused
+var registry = map[int]Error{}
+
+// Internal is the error of the unexpected failures, and of the errors which are not an Error
+var Internal = New(500, 1006, "Internal error")
+
+// New registers the code. It panics when the code is already registered
+func New(httpStatus int, code int, message string) Error {
+	if registered, ok := registry[code]; ok {
+		panic(fmt.Sprintf("error code %d is already registered: %s", code, registered.Message))
+	}
+	e := Error{detail: detail{Code: code, Message: message}, HttpStatus: httpStatus}
+	registry[code] = e
+	return e
+}
+
+// Lookup returns the error registered with the code
+func Lookup(code int) (Error, bool) {
+	e, ok := registry[code]
+	return e, ok
+}
+
+// Codes returns the registered errors sorted by code
+func Codes() []Error {
+	codes := make([]Error, 0, len(registry))
+	for _, e := range registry {
+		codes = append(codes, e)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// From returns the Error of err or of one of its causes
+// Any other error is an internal error wrapping err, so its message does not reach the client
+func From(err error) Error {
+	var e Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal.Wrap(err)
+}
+
+// Implement the error interface
+func (e Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+// Wrap returns a copy of the error with its cause
+func (e Error) Wrap(cause error) Error {
+	e.cause = cause
+	return e
+}
+
+func (e Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches the errors with the same code, whatever their cause
+func (e Error) Is(target error) bool {
+	t, ok := target.(Error)
+	return ok && t.Code == e.Code
+}
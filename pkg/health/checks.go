@@ -0,0 +1,41 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"emvn/pkg/storage"
+	"encoding/hex"
+	"errors"
+)
+
+// MongoCheck pings the database
+func MongoCheck(ping func(ctx context.Context) error) Check {
+	return Check{Name: "mongodb", Run: ping}
+}
+
+// StorageCheck writes a file, reads it back and deletes it, a read-only or full storage is not ready
+func StorageCheck(fileStorage storage.StorageInterface) Check {
+	return Check{Name: "storage", Run: func(ctx context.Context) error {
+		content := make([]byte, 16)
+		if _, err := rand.Read(content); err != nil {
+			return err
+		}
+		// Probes of the instances sharing the storage must not collide
+		path, err := fileStorage.SaveFile(ctx, content, ".readyz-"+hex.EncodeToString(content))
+		if err != nil {
+			return err
+		}
+
+		read, err := fileStorage.GetFile(ctx, path)
+		if err != nil {
+			_ = fileStorage.DeleteFile(context.WithoutCancel(ctx), path)
+			return err
+		}
+		if !bytes.Equal(read, content) {
+			_ = fileStorage.DeleteFile(context.WithoutCancel(ctx), path)
+			return errors.New("read back different content")
+		}
+		return fileStorage.DeleteFile(ctx, path)
+	}}
+}
@@ -0,0 +1,110 @@
+package health
+
+import (
+	"context"
+	"emvn/pkg/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check is a dependency the service needs to serve requests
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// The error of a check is logged, not reported, the probe is not authenticated
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+type Report struct {
+	Status   string                 `json:"status"`
+	Draining bool                   `json:"draining,omitempty"`
+	Checks   map[string]CheckResult `json:"checks"`
+}
+
+// Checker runs the checks of the readiness probe. It is not ready while draining, during the graceful shutdown
+type Checker struct {
+	checks   []Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+var checker *Checker
+
+const defaultTimeout = 2 * time.Second
+
+// InitChecker creates the checker, every check has timeout to answer, 2 seconds when zero
+func InitChecker(timeout time.Duration, checks ...Check) {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	checker = &Checker{checks: checks, timeout: timeout}
+}
+
+func HealthChecker() *Checker {
+	return checker
+}
+
+// Drain makes the instance not ready, so the load balancers stop sending requests before it shuts down
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Ready runs the checks concurrently, the report is up when every check is up and the instance is not draining
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{
+		Status:   StatusUp,
+		Draining: c.draining.Load(),
+		Checks:   make(map[string]CheckResult, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+			mu.Lock()
+			report.Checks[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	if report.Draining {
+		report.Status = StatusDown
+	}
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := CheckResult{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		logger.FromContext(ctx).Error("health", "check", check.Name, "error", err)
+	}
+	return result
+}
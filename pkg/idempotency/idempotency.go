@@ -0,0 +1,49 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Response of the first request of a key, replayed to the retries
+type Response struct {
+	Status int // 0 for 200
+	Header map[string]string
+	Body   []byte
+}
+
+// Record of a key. Response is nil while the first request is in progress
+type Record struct {
+	Fingerprint string
+	Response    *Response
+	ExpiresAt   time.Time
+}
+
+// Store keeps the keys and the responses
+// Like revocation.Store, the in memory implementation only works for one instance, use the mongodb one behind a load balancer
+type Store interface {
+	// Start claims key for a request until expiresAt. When the key is already claimed and not expired,
+	// it returns false and the record of the first request
+	Start(ctx context.Context, key string, fingerprint string, expiresAt time.Time) (Record, bool, error)
+	// Complete stores the response of the request which claimed key, it is kept until expiresAt
+	Complete(ctx context.Context, key string, response Response, expiresAt time.Time) error
+	// Release forgets key, so a retry runs again
+	Release(ctx context.Context, key string) error
+}
+
+// Supported stores for the config, the mongodb one is built with the database by the caller
+const (
+	StoreMemory  = "memory"
+	StoreMongoDB = "mongodb"
+)
+
+// New returns the in memory store, the default
+func New(storeType string) (Store, error) {
+	switch storeType {
+	case "", StoreMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unsupported idempotency store: %s", storeType)
+	}
+}
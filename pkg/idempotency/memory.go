@@ -0,0 +1,73 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Expired records are removed every sweepInterval
+const sweepInterval = time.Minute
+
+// memoryStore keeps the keys of this instance only
+type memoryStore struct {
+	mu        sync.Mutex
+	records   map[string]Record
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{
+		records:   map[string]Record{},
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *memoryStore) Start(ctx context.Context, key string, fingerprint string, expiresAt time.Time) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		s.sweep(now)
+	}
+
+	if record, ok := s.records[key]; ok && record.ExpiresAt.After(now) {
+		return record, false, nil
+	}
+	s.records[key] = Record{Fingerprint: fingerprint, ExpiresAt: expiresAt}
+	return Record{}, true, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, key string, response Response, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return nil
+	}
+	record.Response = &response
+	record.ExpiresAt = expiresAt
+	s.records[key] = record
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+func (s *memoryStore) sweep(now time.Time) {
+	for key, record := range s.records {
+		if !record.ExpiresAt.After(now) {
+			delete(s.records, key)
+		}
+	}
+	s.lastSweep = now
+}
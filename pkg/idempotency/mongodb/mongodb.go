@@ -0,0 +1,85 @@
+package mongodb
+
+import (
+	"context"
+	"emvn/consts"
+	"emvn/database/nosql"
+	"emvn/pkg/idempotency"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Idempotency store on top of NoSQLInterface, shared by the instances
+// The key is the _id, so only one request can claim it. A TTL index on idempotency_keys.expires_at cleans up the expired keys
+type mongoStore struct {
+	noSqlDB nosql.NoSQLInterface
+}
+
+type record struct {
+	Key         string    `bson:"_id"`
+	Fingerprint string    `bson:"fingerprint"`
+	Response    *response `bson:"response,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+type response struct {
+	Status int               `bson:"status"`
+	Header map[string]string `bson:"header"`
+	Body   []byte            `bson:"body"`
+}
+
+func NewStore(noSqlDB nosql.NoSQLInterface) *mongoStore {
+	return &mongoStore{noSqlDB: noSqlDB}
+}
+
+// Start upserts the key when it is missing or expired. When it is claimed, the filter does not match
+// and the upsert fails on the duplicate _id, then the record of the first request is returned
+func (s *mongoStore) Start(ctx context.Context, key string, fingerprint string, expiresAt time.Time) (idempotency.Record, bool, error) {
+	filter := bson.M{"_id": key, "expires_at": bson.M{"$lte": time.Now()}}
+	update := bson.M{
+		"$set":   bson.M{"fingerprint": fingerprint, "expires_at": expiresAt},
+		"$unset": bson.M{"response": ""},
+	}
+	_, err := s.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionIdempotencyKeys, filter, update, options.Update().SetUpsert(true))
+	if err == nil {
+		return idempotency.Record{}, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return idempotency.Record{}, false, err
+	}
+
+	result, err := s.noSqlDB.FindOne(ctx, consts.MongoDBCollectionIdempotencyKeys, bson.M{"_id": key})
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+	var doc record
+	if err := result.Decode(&doc); err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	claimed := idempotency.Record{Fingerprint: doc.Fingerprint, ExpiresAt: doc.ExpiresAt}
+	if doc.Response != nil {
+		claimed.Response = &idempotency.Response{
+			Status: doc.Response.Status,
+			Header: doc.Response.Header,
+			Body:   doc.Response.Body,
+		}
+	}
+	return claimed, false, nil
+}
+
+func (s *mongoStore) Complete(ctx context.Context, key string, res idempotency.Response, expiresAt time.Time) error {
+	_, err := s.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionIdempotencyKeys, bson.M{"_id": key}, bson.M{"$set": bson.M{
+		"response":   response(res),
+		"expires_at": expiresAt,
+	}})
+	return err
+}
+
+func (s *mongoStore) Release(ctx context.Context, key string) error {
+	_, err := s.noSqlDB.DeleteOne(ctx, consts.MongoDBCollectionIdempotencyKeys, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,152 @@
+package keyset
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Keys are loaded from <kid>.pem files of a directory
+const keyFileExt = ".pem"
+
+var (
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrNoSigningKey   = errors.New("no signing key")
+	ErrUnsupportedKey = errors.New("unsupported key type, only RSA and Ed25519 are supported")
+)
+
+// Key is a key identified by its kid. A key without private key can only verify tokens
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+// KeySet signs tokens with one key and verifies them with every key it holds
+// Rotation: add the new key, switch the signing kid to it, then remove the old key once the tokens it signed are expired
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	// HS256 secret of the tokens issued before asymmetric keys, they have no kid
+	legacySecret []byte
+}
+
+// Load reads the keys of dir and signs with the key signingKid
+// When signingKid is empty, tokens are signed with HS256 and legacySecret like before
+// legacySecret keeps verifying HS256 tokens without kid, leave it empty to retire them
+func Load(dir string, signingKid string, legacySecret string) (*KeySet, error) {
+	ks := &KeySet{
+		keys:         map[string]*Key{},
+		legacySecret: []byte(legacySecret),
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*"+keyFileExt))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			key, err := loadKeyFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("load key %s: %w", file, err)
+			}
+			ks.keys[key.ID] = key
+		}
+	}
+
+	if signingKid == "" {
+		if len(ks.legacySecret) == 0 {
+			return nil, ErrNoSigningKey
+		}
+		return ks, nil
+	}
+
+	signing, ok := ks.keys[signingKid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, signingKid)
+	}
+	if signing.private == nil {
+		return nil, fmt.Errorf("%w: %s has no private key", ErrNoSigningKey, signingKid)
+	}
+	ks.signing = signing
+	return ks, nil
+}
+
+func loadKeyFile(file string) (*Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(file), keyFileExt)}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key.public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key.private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key.private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if signer, ok := key.private.(crypto.Signer); ok {
+		key.public = signer.Public()
+	}
+	switch key.public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	return key, nil
+}
+
+// Sign the claims with the signing key, its kid is set in the header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.legacySecret)
+	}
+
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.private)
+}
+
+// Keyfunc resolves the key verifying a token from its kid, to be used with jwt.Parse
+// The algorithm of the token must be the one of the key, a token can not pick how it is verified
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || len(ks.legacySecret) == 0 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return ks.legacySecret, nil
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
@@ -0,0 +1,203 @@
+package keyset
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const legacySecret = "legacy-secret"
+
+// writeKeys writes an RSA key, an Ed25519 key and the public part only of an older RSA key
+func writeKeys(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "rsa", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "ed", "PRIVATE KEY", der)
+
+	der, err = x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "old", "PUBLIC KEY", der)
+	return dir
+}
+
+func writePEM(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+keyFileExt), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeKeys(t)
+
+	ecDir := t.TempDir()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, ecDir, "ec", "PRIVATE KEY", der)
+
+	notPEMDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(notPEMDir, "bad"+keyFileExt), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		dir          string
+		signingKid   string
+		legacySecret string
+		wantErr      error
+		wantAnyErr   bool
+	}{
+		{name: "rsa signing key", dir: dir, signingKid: "rsa"},
+		{name: "ed25519 signing key", dir: dir, signingKid: "ed", legacySecret: legacySecret},
+		{name: "legacy secret only", legacySecret: legacySecret},
+		{name: "nothing to sign with", dir: dir, wantErr: ErrNoSigningKey},
+		{name: "unknown signing kid", dir: dir, signingKid: "missing", wantErr: ErrUnknownKey},
+		{name: "public key can not sign", dir: dir, signingKid: "old", wantErr: ErrNoSigningKey},
+		{name: "unsupported key type", dir: ecDir, legacySecret: legacySecret, wantErr: ErrUnsupportedKey},
+		{name: "not a PEM file", dir: notPEMDir, legacySecret: legacySecret, wantAnyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.dir, tt.signingKid, tt.legacySecret)
+			if tt.wantAnyErr {
+				if err == nil {
+					t.Error("Load() error = nil, want an error")
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyfunc(t *testing.T) {
+	dir := writeKeys(t)
+	rsaSet, err := Load(dir, "rsa", legacySecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edSet, err := Load(dir, "ed", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacySet, err := Load("", "", legacySecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{"sub": "1"}
+	must := func(token string, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return token
+	}
+
+	// HS256 signed with the RSA public key as secret, the classic algorithm confusion
+	publicPEM, err := os.ReadFile(filepath.Join(dir, "old"+keyFileExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	confused.Header["kid"] = "rsa"
+
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	none.Header["kid"] = "rsa"
+
+	rsaAsEd := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	rsaAsEd.Header["kid"] = "rsa"
+
+	// Signed before the rotation, only the public key is left
+	rotated := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	rotated.Header["kid"] = "old"
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	unknown.Header["kid"] = "missing"
+
+	tests := []struct {
+		name    string
+		token   string
+		verify  *KeySet
+		wantErr bool
+	}{
+		{name: "rsa signed", token: must(rsaSet.Sign(claims)), verify: rsaSet},
+		{name: "ed25519 signed", token: must(edSet.Sign(claims)), verify: rsaSet},
+		{name: "previous signing key", token: must(rsaSet.Sign(claims)), verify: edSet},
+		{name: "verify only key", token: must(rotated.SignedString(rsaSet.signing.private)), verify: edSet},
+		{name: "legacy without kid", token: must(legacySet.Sign(claims)), verify: rsaSet},
+		{name: "legacy without legacy secret", token: must(legacySet.Sign(claims)), verify: edSet, wantErr: true},
+		{name: "legacy with another secret", token: must(jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("other"))), verify: rsaSet, wantErr: true},
+		{name: "hs256 with the kid of an rsa key", token: must(confused.SignedString(publicPEM)), verify: rsaSet, wantErr: true},
+		{name: "alg none with a kid", token: must(none.SignedString(jwt.UnsafeAllowNoneSignatureType)), verify: rsaSet, wantErr: true},
+		{name: "alg none without kid", token: must(jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)), verify: rsaSet, wantErr: true},
+		{name: "alg of another key type", token: must(rsaAsEd.SignedString(edSet.signing.private)), verify: rsaSet, wantErr: true},
+		{name: "unknown kid", token: must(unknown.SignedString(rsaSet.signing.private)), verify: rsaSet, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.Parse(tt.token, tt.verify.Keyfunc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	ks, err := Load(writeKeys(t), "rsa", legacySecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := ks.JWKS()
+	want := []struct{ kid, kty, alg string }{
+		{kid: "ed", kty: "OKP", alg: "EdDSA"},
+		{kid: "old", kty: "RSA", alg: "RS256"},
+		{kid: "rsa", kty: "RSA", alg: "RS256"},
+	}
+	if len(jwks.Keys) != len(want) {
+		t.Fatalf("JWKS() = %d keys, want %d, the legacy secret is never published", len(jwks.Keys), len(want))
+	}
+	for i, key := range jwks.Keys {
+		if key.Kid != want[i].kid || key.Kty != want[i].kty || key.Alg != want[i].alg || key.Use != "sig" {
+			t.Errorf("JWKS().Keys[%d] = %+v, want %+v", i, key, want[i])
+		}
+	}
+}
@@ -14,8 +14,13 @@ var LogLelvel = map[string]slog.Level{
 	"error": slog.LevelError,
 }
 
-// Because time limit, I will not implement any custom logger with request id or trace,....
-// TODO: Custom logger with ctx and trace
+// Supported formats for the config
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// NewLogger sets the default logger. The request scoped loggers of FromContext are derived from it
 func NewLogger(logConfig config.LogConfig) {
 	opts := slog.HandlerOptions{
 		Level:     LogLelvel[logConfig.Level],
@@ -25,7 +30,13 @@ func NewLogger(logConfig config.LogConfig) {
 	// TODO: Write to file, log rotation
 	writer := io.MultiWriter(os.Stdout)
 
-	handler := slog.NewTextHandler(writer, &opts)
+	var handler slog.Handler
+	switch logConfig.Format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(writer, &opts)
+	default:
+		handler = slog.NewTextHandler(writer, &opts)
+	}
 
 	logger := slog.New(handler)
 	slog.SetDefault(logger)
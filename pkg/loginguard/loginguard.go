@@ -0,0 +1,149 @@
+package loginguard
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+)
+
+// Attempts are the recent failed sign in of a key, a username or a client IP
+type Attempts struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// Store keeps the failed attempts
+// Like revocation.Store, the implementation can be switched, e.g. to Redis, without touching the auth usecase
+type Store interface {
+	// Get returns zero attempts when the key never failed
+	Get(ctx context.Context, key string) (Attempts, error)
+	// RegisterFailure counts a failure at now. The count restarts when the last failure is older than window
+	RegisterFailure(ctx context.Context, key string, now time.Time, window time.Duration) (Attempts, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset forgets the failures and the lock of the key
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy of a kind of key
+type Policy struct {
+	// Failures before the key is locked, 0 never locks
+	MaxFailures int
+	Lockout     time.Duration
+	// Failures older than Window are forgotten
+	Window time.Duration
+	// After n failures, the next attempt must wait BaseDelay * 2^(n-1), up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+func (p Policy) delay(failures int) time.Duration {
+	if failures == 0 || p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < failures; i++ {
+		if (p.MaxDelay > 0 && delay >= p.MaxDelay) || delay > math.MaxInt64/2 {
+			break
+		}
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Decision of Check. A sign in is allowed when RetryAfter is zero
+type Decision struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+// Guard tracks the failed sign in per username and per client IP
+// The username is tracked even when the user does not exist, so a lock does not tell whether an account exists
+type Guard struct {
+	store    Store
+	username Policy
+	ip       Policy
+	now      func() time.Time
+}
+
+func NewGuard(store Store, username Policy, ip Policy) *Guard {
+	return &Guard{
+		store:    store,
+		username: username,
+		ip:       ip,
+		now:      time.Now,
+	}
+}
+
+func usernameKey(username string) string {
+	return "username:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check whether a sign in can be tried now
+// The IP is checked first, a locked IP is turned down before anything about the username is read
+func (g *Guard) Check(ctx context.Context, username, ip string) (Decision, error) {
+	var decision Decision
+	for _, check := range []struct {
+		key    string
+		policy Policy
+	}{{ipKey(ip), g.ip}, {usernameKey(username), g.username}} {
+		attempts, err := g.store.Get(ctx, check.key)
+		if err != nil {
+			return Decision{}, err
+		}
+
+		now := g.now()
+		if wait := attempts.LockedUntil.Sub(now); wait > 0 {
+			decision.Locked = true
+			decision.RetryAfter = max(decision.RetryAfter, wait)
+			return decision, nil
+		}
+		if now.Sub(attempts.LastFailureAt) > check.policy.Window {
+			continue
+		}
+		if wait := attempts.LastFailureAt.Add(check.policy.delay(attempts.Failures)).Sub(now); wait > 0 {
+			decision.RetryAfter = max(decision.RetryAfter, wait)
+		}
+	}
+	return decision, nil
+}
+
+// Fail records a failed sign in and locks the keys which reached their limit
+func (g *Guard) Fail(ctx context.Context, username, ip string) error {
+	for _, fail := range []struct {
+		key    string
+		policy Policy
+	}{{usernameKey(username), g.username}, {ipKey(ip), g.ip}} {
+		now := g.now()
+		attempts, err := g.store.RegisterFailure(ctx, fail.key, now, fail.policy.Window)
+		if err != nil {
+			return err
+		}
+		if fail.policy.MaxFailures > 0 && attempts.Failures >= fail.policy.MaxFailures {
+			if err := g.store.Lock(ctx, fail.key, now.Add(fail.policy.Lockout)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Succeed forgets the failures of the username
+// The IP is not reset, otherwise an attacker with one valid account could keep guessing the others
+func (g *Guard) Succeed(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, usernameKey(username))
+}
+
+// Unlock a username before its lockout ends
+func (g *Guard) Unlock(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, usernameKey(username))
+}
@@ -0,0 +1,206 @@
+package loginguard
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// memoryStore is a Store kept in a map, it restarts the count like the MongoDB store
+type memoryStore map[string]Attempts
+
+func (s memoryStore) Get(_ context.Context, key string) (Attempts, error) {
+	return s[key], nil
+}
+
+func (s memoryStore) RegisterFailure(_ context.Context, key string, now time.Time, window time.Duration) (Attempts, error) {
+	attempts := s[key]
+	attempts.Key = key
+	if now.Sub(attempts.LastFailureAt) > window {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = now
+	s[key] = attempts
+	return attempts, nil
+}
+
+func (s memoryStore) Lock(_ context.Context, key string, until time.Time) error {
+	attempts := s[key]
+	attempts.LockedUntil = until
+	s[key] = attempts
+	return nil
+}
+
+func (s memoryStore) Reset(_ context.Context, key string) error {
+	delete(s, key)
+	return nil
+}
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	tests := []struct {
+		name     string
+		policy   Policy
+		failures int
+		want     time.Duration
+	}{
+		{name: "no failure", policy: policy, failures: 0, want: 0},
+		{name: "first failure", policy: policy, failures: 1, want: time.Second},
+		{name: "second failure doubles", policy: policy, failures: 2, want: 2 * time.Second},
+		{name: "fourth failure", policy: policy, failures: 4, want: 8 * time.Second},
+		{name: "capped", policy: policy, failures: 5, want: 10 * time.Second},
+		{name: "capped far past the limit", policy: policy, failures: 1000, want: 10 * time.Second},
+		{name: "no base delay", policy: Policy{MaxDelay: time.Second}, failures: 3, want: 0},
+		{name: "no max delay", policy: Policy{BaseDelay: time.Second}, failures: 3, want: 4 * time.Second},
+		{name: "no max delay does not overflow", policy: Policy{BaseDelay: time.Second}, failures: 1000, want: time.Second << 33},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.delay(tt.failures); got != tt.want {
+				t.Errorf("delay(%d) = %v, want %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	usernamePolicy := Policy{MaxFailures: 3, Lockout: 15 * time.Minute, Window: time.Hour, BaseDelay: time.Second, MaxDelay: time.Minute}
+	ipPolicy := Policy{MaxFailures: 5, Lockout: time.Hour, Window: time.Hour}
+
+	tests := []struct {
+		name string
+		// failures of each username, all from ip 10.0.0.1, one second apart
+		failures []string
+		// elapsed time between the last failure and the check
+		elapsed  time.Duration
+		username string
+		ip       string
+		want     Decision
+	}{
+		{
+			name:     "no failure",
+			username: "alice",
+			ip:       "10.0.0.1",
+			want:     Decision{},
+		},
+		{
+			name:     "first failure waits the base delay",
+			failures: []string{"alice"},
+			username: "alice",
+			ip:       "10.0.0.1",
+			want:     Decision{RetryAfter: time.Second},
+		},
+		{
+			name:     "second failure doubles the delay",
+			failures: []string{"alice", "alice"},
+			elapsed:  500 * time.Millisecond,
+			username: "alice",
+			ip:       "10.0.0.2",
+			want:     Decision{RetryAfter: 1500 * time.Millisecond},
+		},
+		{
+			name:     "delay elapsed",
+			failures: []string{"alice", "alice"},
+			elapsed:  2 * time.Second,
+			username: "alice",
+			ip:       "10.0.0.1",
+			want:     Decision{},
+		},
+		{
+			name:     "username is case insensitive",
+			failures: []string{"Alice"},
+			username: "ALICE",
+			ip:       "10.0.0.2",
+			want:     Decision{RetryAfter: time.Second},
+		},
+		{
+			name:     "username locked",
+			failures: []string{"alice", "alice", "alice"},
+			elapsed:  time.Minute,
+			username: "alice",
+			ip:       "10.0.0.2",
+			want:     Decision{Locked: true, RetryAfter: 14 * time.Minute},
+		},
+		{
+			name:     "username lockout over",
+			failures: []string{"alice", "alice", "alice"},
+			elapsed:  16 * time.Minute,
+			username: "alice",
+			ip:       "10.0.0.2",
+			want:     Decision{},
+		},
+		{
+			name:     "ip locked for every username",
+			failures: []string{"a", "b", "c", "d", "e"},
+			elapsed:  time.Minute,
+			username: "bob",
+			ip:       "10.0.0.1",
+			want:     Decision{Locked: true, RetryAfter: 59 * time.Minute},
+		},
+		{
+			name:     "other ip is not locked",
+			failures: []string{"a", "b", "c", "d", "e"},
+			elapsed:  time.Minute,
+			username: "bob",
+			ip:       "10.0.0.2",
+			want:     Decision{},
+		},
+		{
+			name:     "failures older than the window are forgotten",
+			failures: []string{"alice"},
+			elapsed:  2 * time.Hour,
+			username: "alice",
+			ip:       "10.0.0.1",
+			want:     Decision{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+			guard := NewGuard(memoryStore{}, usernamePolicy, ipPolicy)
+			guard.now = func() time.Time { return now }
+
+			for i, username := range tt.failures {
+				if i > 0 {
+					now = now.Add(time.Second)
+				}
+				if err := guard.Fail(ctx, username, "10.0.0.1"); err != nil {
+					t.Fatalf("Fail() error = %v", err)
+				}
+			}
+			now = now.Add(tt.elapsed)
+
+			got, err := guard.Check(ctx, tt.username, tt.ip)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuardSucceed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	guard := NewGuard(memoryStore{}, Policy{MaxFailures: 1, Lockout: time.Hour, Window: time.Hour}, Policy{MaxFailures: 1, Lockout: time.Hour, Window: time.Hour})
+	guard.now = func() time.Time { return now }
+
+	if err := guard.Fail(ctx, "alice", "10.0.0.1"); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+	if err := guard.Succeed(ctx, "alice"); err != nil {
+		t.Fatalf("Succeed() error = %v", err)
+	}
+
+	// The username is forgotten but the IP stays locked
+	if got, _ := guard.Check(ctx, "alice", "10.0.0.2"); got != (Decision{}) {
+		t.Errorf("Check() of the username = %+v, want no lock", got)
+	}
+	if got, _ := guard.Check(ctx, "bob", "10.0.0.1"); !got.Locked {
+		t.Errorf("Check() of the ip = %+v, want a lock", got)
+	}
+}
@@ -0,0 +1,74 @@
+package mongodb
+
+import (
+	"context"
+	"emvn/consts"
+	"emvn/database/nosql"
+	"emvn/pkg/loginguard"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Login attempts store on top of NoSQLInterface
+type mongoStore struct {
+	noSqlDB nosql.NoSQLInterface
+}
+
+type attempts struct {
+	Key           string    `bson:"key"`
+	Failures      int       `bson:"failures"`
+	LastFailureAt time.Time `bson:"last_failure_at"`
+	LockedUntil   time.Time `bson:"locked_until"`
+}
+
+func NewStore(noSqlDB nosql.NoSQLInterface) *mongoStore {
+	return &mongoStore{noSqlDB: noSqlDB}
+}
+
+func (s *mongoStore) Get(ctx context.Context, key string) (loginguard.Attempts, error) {
+	result, err := s.noSqlDB.FindOne(ctx, consts.MongoDBCollectionLoginAttempts, bson.M{"key": key})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return loginguard.Attempts{Key: key}, nil
+		}
+		return loginguard.Attempts{}, err
+	}
+
+	var doc attempts
+	if err := result.Decode(&doc); err != nil {
+		return loginguard.Attempts{}, err
+	}
+	return loginguard.Attempts(doc), nil
+}
+
+// RegisterFailure is a single pipeline update, so concurrent failures are all counted
+func (s *mongoStore) RegisterFailure(ctx context.Context, key string, now time.Time, window time.Duration) (loginguard.Attempts, error) {
+	update := bson.A{bson.M{"$set": bson.M{
+		"failures": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{"$last_failure_at", now.Add(-window)}},
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failures", 0}}, 1}},
+			1,
+		}},
+		"last_failure_at": now,
+	}}}
+	_, err := s.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionLoginAttempts, bson.M{"key": key}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return loginguard.Attempts{}, err
+	}
+	return s.Get(ctx, key)
+}
+
+func (s *mongoStore) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := s.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionLoginAttempts, bson.M{"key": key}, bson.M{"$set": bson.M{"locked_until": until}})
+	return err
+}
+
+func (s *mongoStore) Reset(ctx context.Context, key string) error {
+	_, err := s.noSqlDB.UpdateOne(ctx, consts.MongoDBCollectionLoginAttempts, bson.M{"key": key},
+		bson.M{"$set": bson.M{"failures": 0, "locked_until": time.Time{}}})
+	return err
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "emvn"
+
+// Registry holds the metrics of the service and of the Go runtime, it is served by Handler
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mongoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_command_duration_seconds",
+		Help:      "Duration of the Mongo commands by command name and outcome.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"command", "outcome"})
+
+	storageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_operation_duration_seconds",
+		Help:      "Duration of the file storage operations by operation and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "outcome"})
+	storageBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "storage_bytes_total",
+		Help:      "Bytes written to and read from the file storage.",
+	}, []string{"operation"})
+
+	uploadBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_bytes_total",
+		Help:      "Bytes received by the upload routes.",
+	}, []string{"kind"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		mongoDuration,
+		storageDuration, storageBytes,
+		uploadBytes,
+	)
+}
+
+// Handler serves the metrics in the Prometheus format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveHTTPRequest records a request. route is the template, e.g. /playlist/get/:id, not the path, to keep the label set small
+func ObserveHTTPRequest(method string, route string, status int, duration time.Duration) {
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	httpRequests.With(labels).Inc()
+	httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+// ObserveMongoCommand records a command of the Mongo command monitor
+func ObserveMongoCommand(command string, duration time.Duration, err error) {
+	mongoDuration.WithLabelValues(command, outcome(err)).Observe(duration.Seconds())
+}
+
+// ObserveStorage records a storage operation, with the bytes read or written when it succeeded
+func ObserveStorage(operation string, duration time.Duration, bytes int, err error) {
+	storageDuration.WithLabelValues(operation, outcome(err)).Observe(duration.Seconds())
+	if err == nil && bytes > 0 {
+		storageBytes.WithLabelValues(operation).Add(float64(bytes))
+	}
+}
+
+// AddUploadBytes counts the bytes of an upload, kind is what is uploaded, e.g. track
+func AddUploadBytes(kind string, bytes int64) {
+	uploadBytes.WithLabelValues(kind).Add(float64(bytes))
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
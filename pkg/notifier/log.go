@@ -0,0 +1,18 @@
+package notifier
+
+import (
+	"context"
+	"log/slog"
+)
+
+// logNotifier writes the messages in the logs, for local use only since the logs then contain secrets
+type logNotifier struct{}
+
+func NewLogNotifier() Notifier {
+	return logNotifier{}
+}
+
+func (logNotifier) Send(ctx context.Context, msg Message) error {
+	slog.Info("notification", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
@@ -0,0 +1,37 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+)
+
+// Message sent to a user, e.g. a password reset token
+type Message struct {
+	To      string // recipient, the email of the user or its username when it has none
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users
+// Like StorageInterface, the implementation can be switched, e.g. to email or SMS, without touching the usecases
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Supported notifiers for the config
+const (
+	TypeLog  = "log"
+	TypeFile = "file"
+)
+
+// New returns the notifier of the type. The log notifier is the default
+func New(notifierType string, filePath string) (Notifier, error) {
+	switch notifierType {
+	case "", TypeLog:
+		return NewLogNotifier(), nil
+	case TypeFile:
+		return NewFileNotifier(filePath)
+	default:
+		return nil, fmt.Errorf("unsupported notifier: %s", notifierType)
+	}
+}
@@ -0,0 +1,42 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims of a verified id token
+type Claims jwt.MapClaims
+
+func (c Claims) GetExpirationTime() (*jwt.NumericDate, error) {
+	return jwt.MapClaims(c).GetExpirationTime()
+}
+func (c Claims) GetIssuedAt() (*jwt.NumericDate, error)  { return jwt.MapClaims(c).GetIssuedAt() }
+func (c Claims) GetNotBefore() (*jwt.NumericDate, error) { return jwt.MapClaims(c).GetNotBefore() }
+func (c Claims) GetIssuer() (string, error)              { return jwt.MapClaims(c).GetIssuer() }
+func (c Claims) GetSubject() (string, error)             { return jwt.MapClaims(c).GetSubject() }
+func (c Claims) GetAudience() (jwt.ClaimStrings, error)  { return jwt.MapClaims(c).GetAudience() }
+
+// String returns a string claim, empty when it is missing or not a string
+func (c Claims) String(name string) string {
+	value, _ := c[name].(string)
+	return value
+}
+
+// NewPKCE returns a code verifier and its S256 challenge (RFC 7636)
+func NewPKCE() (verifier string, challenge string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(b)
+	return verifier, S256Challenge(verifier), nil
+}
+
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,92 @@
+package password
+
+import "errors"
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+	ErrTooLong          = errors.New("password is too long for the hash algorithm")
+)
+
+// Algorithm hashes passwords into a self describing encoded string, the salt and the parameters are part of it
+type Algorithm interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (bool, error)
+	// Identifies reports whether encoded has been produced by this algorithm
+	Identifies(encoded string) bool
+	// NeedsRehash reports whether encoded has been produced with other parameters than the current ones
+	NeedsRehash(encoded string) bool
+}
+
+// Hasher hashes new passwords with the current algorithm and still verifies the hashes of the legacy algorithms
+// A hash of another algorithm or with outdated parameters is reported by Verify, so it can be replaced on sign in
+type Hasher struct {
+	current Algorithm
+	legacy  []Algorithm
+}
+
+func NewHasher(current Algorithm, legacy ...Algorithm) *Hasher {
+	return &Hasher{
+		current: current,
+		legacy:  legacy,
+	}
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Verify reports whether password matches encoded, and when it does, whether encoded should be replaced by a new Hash
+func (h *Hasher) Verify(password, encoded string) (match bool, rehash bool, err error) {
+	if h.current.Identifies(encoded) {
+		match, err = h.current.Verify(password, encoded)
+		return match, match && h.current.NeedsRehash(encoded), err
+	}
+	for _, algorithm := range h.legacy {
+		if algorithm.Identifies(encoded) {
+			match, err = algorithm.Verify(password, encoded)
+			return match, match, err
+		}
+	}
+	return false, false, ErrUnknownAlgorithm
+}
+
+// Supported algorithms for the config
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// Cost of the hashes created before the hasher was configurable
+const defaultBcryptCost = 14
+
+// New returns a hasher hashing with algorithm, argon2id by default, which verifies the other algorithm too
+// Zero parameters take their default value
+func New(algorithm string, argon2id Argon2id, bcrypt Bcrypt) (*Hasher, error) {
+	if argon2id.Memory == 0 {
+		argon2id.Memory = DefaultArgon2id.Memory
+	}
+	if argon2id.Iterations == 0 {
+		argon2id.Iterations = DefaultArgon2id.Iterations
+	}
+	if argon2id.Parallelism == 0 {
+		argon2id.Parallelism = DefaultArgon2id.Parallelism
+	}
+	if argon2id.SaltLength == 0 {
+		argon2id.SaltLength = DefaultArgon2id.SaltLength
+	}
+	if argon2id.KeyLength == 0 {
+		argon2id.KeyLength = DefaultArgon2id.KeyLength
+	}
+	if bcrypt.Cost == 0 {
+		bcrypt.Cost = defaultBcryptCost
+	}
+
+	switch algorithm {
+	case "", AlgorithmArgon2id:
+		return NewHasher(argon2id, bcrypt), nil
+	case AlgorithmBcrypt:
+		return NewHasher(bcrypt, argon2id), nil
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+}
@@ -0,0 +1,183 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cheap parameters, the tests do not need a slow hash
+var (
+	testArgon2id = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	testBcrypt   = Bcrypt{Cost: bcrypt.MinCost}
+)
+
+func TestArgon2idHashVerify(t *testing.T) {
+	encoded, err := testArgon2id.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("Hash() = %q, want the PHC string format", encoded)
+	}
+	if other, _ := testArgon2id.Hash("correct horse"); other == encoded {
+		t.Error("Hash() twice = the same hash, want a new salt each time")
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		encoded  string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "match", password: "correct horse", encoded: encoded, want: true},
+		{name: "mismatch", password: "correct horse!", encoded: encoded},
+		{name: "empty password", password: "", encoded: encoded},
+		// The parameters of the hash are used, not the ones of the verifier
+		{name: "other parameters", password: "correct horse", encoded: mustHash(t, Argon2id{Memory: 128, Iterations: 2, Parallelism: 2, SaltLength: 8, KeyLength: 16}, "correct horse"), want: true},
+		{name: "wrong version", password: "correct horse", encoded: strings.Replace(encoded, "v=19", "v=16", 1), wantErr: true},
+		{name: "missing part", password: "correct horse", encoded: encoded[:strings.LastIndex(encoded, "$")], wantErr: true},
+		{name: "bad parameters", password: "correct horse", encoded: strings.Replace(encoded, "m=64", "m=x", 1), wantErr: true},
+		{name: "bad salt", password: "correct horse", encoded: strings.Replace(encoded, "p=1$", "p=1$!", 1), wantErr: true},
+		{name: "empty key", password: "correct horse", encoded: encoded[:strings.LastIndex(encoded, "$")+1], wantErr: true},
+		{name: "bcrypt hash", password: "correct horse", encoded: mustHash(t, testBcrypt, "correct horse"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testArgon2id.Verify(tt.password, tt.encoded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		want    bool
+	}{
+		{name: "same parameters", encoded: mustHash(t, testArgon2id, "pw")},
+		{name: "other memory", encoded: mustHash(t, Argon2id{Memory: 128, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, "pw"), want: true},
+		{name: "other iterations", encoded: mustHash(t, Argon2id{Memory: 64, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}, "pw"), want: true},
+		{name: "other parallelism", encoded: mustHash(t, Argon2id{Memory: 64, Iterations: 1, Parallelism: 2, SaltLength: 16, KeyLength: 32}, "pw"), want: true},
+		{name: "other salt length", encoded: mustHash(t, Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 8, KeyLength: 32}, "pw"), want: true},
+		{name: "other key length", encoded: mustHash(t, Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 16}, "pw"), want: true},
+		{name: "invalid hash", encoded: "$argon2id$", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testArgon2id.NeedsRehash(tt.encoded); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBcryptMaxLength(t *testing.T) {
+	long := strings.Repeat("a", bcryptMaxLength+1)
+	if _, err := testBcrypt.Hash(long); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Hash() error = %v, want ErrTooLong", err)
+	}
+
+	// A longer password sharing the first 72 bytes must not match
+	encoded := mustHash(t, testBcrypt, long[:bcryptMaxLength])
+	tests := []struct {
+		name     string
+		password string
+		want     bool
+	}{
+		{name: "72 bytes", password: long[:bcryptMaxLength], want: true},
+		{name: "73 bytes", password: long},
+		{name: "71 bytes", password: long[:bcryptMaxLength-1]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testBcrypt.Verify(tt.password, encoded)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasherVerify(t *testing.T) {
+	hasher := NewHasher(testArgon2id, testBcrypt)
+
+	tests := []struct {
+		name       string
+		password   string
+		encoded    string
+		wantMatch  bool
+		wantRehash bool
+		wantErr    error
+	}{
+		{name: "current algorithm", password: "pw", encoded: mustHash(t, testArgon2id, "pw"), wantMatch: true},
+		{name: "current algorithm mismatch", password: "other", encoded: mustHash(t, testArgon2id, "pw")},
+		{name: "outdated parameters", password: "pw", encoded: mustHash(t, Argon2id{Memory: 128, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, "pw"), wantMatch: true, wantRehash: true},
+		{name: "outdated parameters mismatch", password: "other", encoded: mustHash(t, Argon2id{Memory: 128, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, "pw")},
+		{name: "legacy bcrypt", password: "pw", encoded: mustHash(t, testBcrypt, "pw"), wantMatch: true, wantRehash: true},
+		{name: "legacy bcrypt mismatch", password: "other", encoded: mustHash(t, testBcrypt, "pw")},
+		{name: "unknown algorithm", password: "pw", encoded: "$1$salt$hash", wantErr: ErrUnknownAlgorithm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, rehash, err := hasher.Verify(tt.password, tt.encoded)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if match != tt.wantMatch || rehash != tt.wantRehash {
+				t.Errorf("Verify() = %v, %v, want %v, %v", match, rehash, tt.wantMatch, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		want      Algorithm
+		wantErr   bool
+	}{
+		{algorithm: "", want: DefaultArgon2id},
+		{algorithm: AlgorithmArgon2id, want: DefaultArgon2id},
+		{algorithm: AlgorithmBcrypt, want: Bcrypt{Cost: defaultBcryptCost}},
+		{algorithm: "md5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			hasher, err := New(tt.algorithm, Argon2id{}, Bcrypt{})
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownAlgorithm) {
+					t.Errorf("New() error = %v, want ErrUnknownAlgorithm", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if hasher.current != tt.want {
+				t.Errorf("New() hashes with %+v, want %+v", hasher.current, tt.want)
+			}
+		})
+	}
+}
+
+func mustHash(t *testing.T, algorithm Algorithm, password string) string {
+	t.Helper()
+	encoded, err := algorithm.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	return encoded
+}
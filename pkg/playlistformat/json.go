@@ -0,0 +1,16 @@
+package playlistformat
+
+import "encoding/json"
+
+func encodeJSON(playlist Playlist) ([]byte, error) {
+	if playlist.Entries == nil {
+		playlist.Entries = []Entry{}
+	}
+	return json.MarshalIndent(playlist, "", "  ")
+}
+
+func decodeJSON(data []byte) (Playlist, error) {
+	var playlist Playlist
+	err := json.Unmarshal(data, &playlist)
+	return playlist, err
+}
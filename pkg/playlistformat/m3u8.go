@@ -0,0 +1,98 @@
+package playlistformat
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Extended M3U, UTF-8 encoded
+// #EXTINF carries duration and "artist - title", #EXTALB the album
+// #EXT-X-EMVN-ID is our own tag with the track id, players ignore unknown tags
+const (
+	m3u8Header    = "#EXTM3U"
+	m3u8Playlist  = "#PLAYLIST:"
+	m3u8Info      = "#EXTINF:"
+	m3u8Album     = "#EXTALB:"
+	m3u8TrackID   = "#EXT-X-EMVN-ID:"
+	m3u8Separator = " - "
+)
+
+func encodeM3U8(playlist Playlist) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(m3u8Header + "\n")
+	if playlist.Title != "" {
+		buf.WriteString(m3u8Playlist + oneLine(playlist.Title) + "\n")
+	}
+	for _, entry := range playlist.Entries {
+		display := oneLine(entry.Title)
+		if entry.Artist != "" {
+			display = oneLine(entry.Artist) + m3u8Separator + display
+		}
+		duration := entry.Duration
+		if duration <= 0 {
+			duration = -1
+		}
+		fmt.Fprintf(&buf, "%s%d,%s\n", m3u8Info, duration, display)
+		if entry.Album != "" {
+			buf.WriteString(m3u8Album + oneLine(entry.Album) + "\n")
+		}
+		if entry.ID != "" {
+			buf.WriteString(m3u8TrackID + entry.ID + "\n")
+		}
+		buf.WriteString(oneLine(entry.Location) + "\n")
+	}
+	return buf.Bytes()
+}
+
+func decodeM3U8(data []byte) (Playlist, error) {
+	var playlist Playlist
+	var current Entry
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line == m3u8Header:
+		case strings.HasPrefix(line, m3u8Playlist):
+			playlist.Title = strings.TrimPrefix(line, m3u8Playlist)
+		case strings.HasPrefix(line, m3u8Info):
+			info := strings.TrimPrefix(line, m3u8Info)
+			durationStr, display, _ := strings.Cut(info, ",")
+			// Attributes like tvg-id="..." may follow the duration
+			durationStr, _, _ = strings.Cut(durationStr, " ")
+			if duration, err := strconv.Atoi(durationStr); err == nil && duration > 0 {
+				current.Duration = duration
+			}
+			if artist, title, ok := strings.Cut(display, m3u8Separator); ok {
+				current.Artist, current.Title = strings.TrimSpace(artist), strings.TrimSpace(title)
+			} else {
+				current.Title = strings.TrimSpace(display)
+			}
+		case strings.HasPrefix(line, m3u8Album):
+			current.Album = strings.TrimPrefix(line, m3u8Album)
+		case strings.HasPrefix(line, m3u8TrackID):
+			current.ID = strings.TrimPrefix(line, m3u8TrackID)
+		case strings.HasPrefix(line, "#"):
+			// Unknown tag or comment
+		default:
+			current.Location = line
+			playlist.Entries = append(playlist.Entries, current)
+			current = Entry{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Playlist{}, err
+	}
+	if len(playlist.Entries) == 0 && !bytes.Contains(data, []byte(m3u8Header)) {
+		return Playlist{}, errors.New("not a m3u8 playlist")
+	}
+	return playlist, nil
+}
+
+// oneLine avoids breaking the line based format with values containing new lines
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,106 @@
+package playlistformat
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// This package converts playlists from/to the common playlist file formats.
+// It does not know about the database, the usecase layer maps tracks to entries and matches imported entries to tracks
+
+type Format string
+
+const (
+	FormatM3U8 Format = "m3u8"
+	FormatXSPF Format = "xspf"
+	FormatJSON Format = "json"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported playlist format")
+
+// Playlist is the format independent representation of a playlist file
+type Playlist struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	Entries     []Entry `json:"entries"`
+}
+
+// Entry is a track of a playlist file. Any field may be empty in an imported file
+type Entry struct {
+	ID       string `json:"id,omitempty"` // track id in this service
+	Title    string `json:"title,omitempty"`
+	Artist   string `json:"artist,omitempty"`
+	Album    string `json:"album,omitempty"`
+	Duration int    `json:"duration,omitempty"` // seconds
+	Location string `json:"location,omitempty"` // stream URL or file path
+}
+
+// FileName returns the last element of the location, used to match an entry by file name
+func (e Entry) FileName() string {
+	if e.Location == "" {
+		return ""
+	}
+	location := strings.ReplaceAll(e.Location, "\\", "/")
+	if i := strings.IndexAny(location, "?#"); i >= 0 {
+		location = location[:i]
+	}
+	name := path.Base(location)
+	// Locations written as URL are percent encoded
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	return name
+}
+
+// ParseFormat validates a format name, it also accepts a file name and uses its extension
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.LastIndex(s, "."); i >= 0 {
+		s = s[i+1:]
+	}
+	switch Format(s) {
+	case FormatM3U8, FormatXSPF, FormatJSON:
+		return Format(s), nil
+	case "m3u":
+		return FormatM3U8, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// ContentType of the encoded file
+func (f Format) ContentType() string {
+	switch f {
+	case FormatM3U8:
+		return "audio/x-mpegurl; charset=utf-8"
+	case FormatXSPF:
+		return "application/xspf+xml; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+func Encode(format Format, playlist Playlist) ([]byte, error) {
+	switch format {
+	case FormatM3U8:
+		return encodeM3U8(playlist), nil
+	case FormatXSPF:
+		return encodeXSPF(playlist)
+	case FormatJSON:
+		return encodeJSON(playlist)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func Decode(format Format, data []byte) (Playlist, error) {
+	switch format {
+	case FormatM3U8:
+		return decodeM3U8(data)
+	case FormatXSPF:
+		return decodeXSPF(data)
+	case FormatJSON:
+		return decodeJSON(data)
+	}
+	return Playlist{}, ErrUnsupportedFormat
+}
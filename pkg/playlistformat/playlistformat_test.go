@@ -0,0 +1,170 @@
+package playlistformat
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var samplePlaylist = Playlist{
+	Title:       "Road trip",
+	Description: "Songs for the road",
+	Entries: []Entry{
+		{
+			ID:       "6650f1c2a1b2c3d4e5f60718",
+			Title:    "Highway",
+			Artist:   "The Drivers",
+			Album:    "Miles",
+			Duration: 215,
+			Location: "https://api.emvn.example/v1/tracks/6650f1c2a1b2c3d4e5f60718/audio",
+		},
+		{
+			Title:    "Untitled",
+			Location: "/v1/tracks/6650f1c2a1b2c3d4e5f60719/audio",
+		},
+	},
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	// m3u8 has no place for the description
+	m3u8Playlist := samplePlaylist
+	m3u8Playlist.Description = ""
+
+	tests := []struct {
+		format Format
+		want   Playlist
+	}{
+		{format: FormatM3U8, want: m3u8Playlist},
+		{format: FormatXSPF, want: samplePlaylist},
+		{format: FormatJSON, want: samplePlaylist},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			data, err := Encode(tt.format, samplePlaylist)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			got, err := Decode(tt.format, data)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode(Encode()) = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeM3U8(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Playlist
+		wantErr bool
+	}{
+		{
+			name: "byte order mark and attributes",
+			data: "\xef\xbb\xbf#EXTM3U\n#EXTINF:180 tvg-id=\"x\",Artist - Title\n/music/a.mp3\n",
+			want: Playlist{Entries: []Entry{{Title: "Title", Artist: "Artist", Duration: 180, Location: "/music/a.mp3"}}},
+		},
+		{
+			name: "title without artist and unknown duration",
+			data: "#EXTM3U\n#EXTINF:-1,Only a title\n# a comment\nb.mp3\n",
+			want: Playlist{Entries: []Entry{{Title: "Only a title", Location: "b.mp3"}}},
+		},
+		{
+			name: "plain m3u without tags",
+			data: "a.mp3\r\nb.mp3\r\n",
+			want: Playlist{Entries: []Entry{{Location: "a.mp3"}, {Location: "b.mp3"}}},
+		},
+		{
+			name: "empty playlist with header",
+			data: "#EXTM3U\n#PLAYLIST:Empty\n",
+			want: Playlist{Title: "Empty"},
+		},
+		{
+			name:    "not a playlist",
+			data:    "#just a comment\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(FormatM3U8, []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeM3U8KeepsOneLinePerValue(t *testing.T) {
+	data, err := Encode(FormatM3U8, Playlist{Entries: []Entry{{Title: "Two\nlines", Location: "a.mp3\n#EXTINF:1,injected"}}})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := Decode(FormatM3U8, data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(got.Entries) != 1 {
+		t.Fatalf("Decode() = %d entries, want 1", len(got.Entries))
+	}
+}
+
+func TestDecodeXSPFInvalid(t *testing.T) {
+	if _, err := Decode(FormatXSPF, []byte("<playlist><trackList>")); err == nil {
+		t.Error("Decode() error = nil, want an error")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{in: "m3u8", want: FormatM3U8},
+		{in: "M3U", want: FormatM3U8},
+		{in: "road trip.xspf", want: FormatXSPF},
+		{in: " JSON ", want: FormatJSON},
+		{in: "pls", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseFormat(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedFormat) {
+					t.Errorf("ParseFormat() error = %v, want ErrUnsupportedFormat", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseFormat() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntryFileName(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{location: "", want: ""},
+		{location: "/music/song.mp3", want: "song.mp3"},
+		{location: `C:\Music\song.mp3`, want: "song.mp3"},
+		{location: "https://cdn.example/a/My%20Song.mp3?sig=1#t=10", want: "My Song.mp3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			if got := (Entry{Location: tt.location}).FileName(); got != tt.want {
+				t.Errorf("FileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
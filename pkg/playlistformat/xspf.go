@@ -0,0 +1,87 @@
+package playlistformat
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// XML Shareable Playlist Format, https://www.xspf.org/spec
+// Duration is in milliseconds. The track id is stored as identifier with our own urn prefix
+const (
+	xspfNamespace = "http://xspf.org/ns/0/"
+	xspfIDPrefix  = "urn:emvn:track:"
+)
+
+type xspfPlaylist struct {
+	XMLName    xml.Name    `xml:"playlist"`
+	Version    string      `xml:"version,attr"`
+	Namespace  string      `xml:"xmlns,attr"`
+	Title      string      `xml:"title,omitempty"`
+	Annotation string      `xml:"annotation,omitempty"`
+	Tracks     []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location   string `xml:"location,omitempty"`
+	Identifier string `xml:"identifier,omitempty"`
+	Title      string `xml:"title,omitempty"`
+	Creator    string `xml:"creator,omitempty"`
+	Album      string `xml:"album,omitempty"`
+	Duration   int    `xml:"duration,omitempty"`
+}
+
+func encodeXSPF(playlist Playlist) ([]byte, error) {
+	out := xspfPlaylist{
+		Version:    "1",
+		Namespace:  xspfNamespace,
+		Title:      playlist.Title,
+		Annotation: playlist.Description,
+		Tracks:     make([]xspfTrack, 0, len(playlist.Entries)),
+	}
+	for _, entry := range playlist.Entries {
+		track := xspfTrack{
+			Location: entry.Location,
+			Title:    entry.Title,
+			Creator:  entry.Artist,
+			Album:    entry.Album,
+			Duration: entry.Duration * 1000,
+		}
+		if entry.ID != "" {
+			track.Identifier = xspfIDPrefix + entry.ID
+		}
+		out.Tracks = append(out.Tracks, track)
+	}
+
+	data, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func decodeXSPF(data []byte) (Playlist, error) {
+	var in xspfPlaylist
+	if err := xml.Unmarshal(data, &in); err != nil {
+		return Playlist{}, err
+	}
+
+	playlist := Playlist{
+		Title:       in.Title,
+		Description: in.Annotation,
+		Entries:     make([]Entry, 0, len(in.Tracks)),
+	}
+	for _, track := range in.Tracks {
+		entry := Entry{
+			Location: strings.TrimSpace(track.Location),
+			Title:    track.Title,
+			Artist:   track.Creator,
+			Album:    track.Album,
+			Duration: track.Duration / 1000,
+		}
+		if strings.HasPrefix(track.Identifier, xspfIDPrefix) {
+			entry.ID = strings.TrimPrefix(track.Identifier, xspfIDPrefix)
+		}
+		playlist.Entries = append(playlist.Entries, entry)
+	}
+	return playlist, nil
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Limit of a token bucket: it holds up to Burst requests and refills at Rate requests per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns the limit of requests per minute with a burst
+func PerMinute(requests int, burst int) Limit {
+	return Limit{Rate: float64(requests) / 60, Burst: burst}
+}
+
+// Result of taking a token
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// ResetAfter is the time until the bucket is full again
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request is allowed, zero when allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets
+// Like revocation.Store, the in memory implementation can be switched to a shared one, e.g. Redis, when running several instances
+type Store interface {
+	// Take a token of the bucket of key
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Supported stores for the config
+const (
+	StoreMemory = "memory"
+)
+
+// New returns the store of the type. The in memory store is the default
+func New(storeType string) (Store, error) {
+	switch storeType {
+	case "", StoreMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate limit store: %s", storeType)
+	}
+}
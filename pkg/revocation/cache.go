@@ -0,0 +1,127 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cachedStore avoids a database round trip on every authenticated request
+// Revocations are cached until the token expires, because they can not be undone
+// Lookups which found nothing are cached for ttl, so a revocation made on another instance is seen after at most ttl
+type cachedStore struct {
+	store Store
+	ttl   time.Duration
+
+	mu            sync.RWMutex
+	revoked       map[string]time.Time // jti -> expiration of the token
+	notRevoked    map[string]time.Time // jti -> expiration of the cache entry
+	revokedBefore map[string]cachedTime
+}
+
+type cachedTime struct {
+	value     time.Time
+	expiresAt time.Time
+}
+
+func NewCachedStore(store Store, ttl time.Duration) Store {
+	return &cachedStore{
+		store:         store,
+		ttl:           ttl,
+		revoked:       map[string]time.Time{},
+		notRevoked:    map[string]time.Time{},
+		revokedBefore: map[string]cachedTime{},
+	}
+}
+
+func (s *cachedStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := s.store.Revoke(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[jti] = expiresAt
+	delete(s.notRevoked, jti)
+	return nil
+}
+
+func (s *cachedStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	now := time.Now()
+	s.mu.RLock()
+	_, revoked := s.revoked[jti]
+	notRevokedUntil, notRevoked := s.notRevoked[jti]
+	s.mu.RUnlock()
+	if revoked {
+		return true, nil
+	}
+	if notRevoked && now.Before(notRevokedUntil) {
+		return false, nil
+	}
+
+	revoked, err := s.store.IsRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictExpired(now)
+	if revoked {
+		// We don't know the expiration of the token here, keep it as long as a negative entry at least
+		s.revoked[jti] = now.Add(s.ttl)
+	} else {
+		s.notRevoked[jti] = now.Add(s.ttl)
+	}
+	return revoked, nil
+}
+
+func (s *cachedStore) RevokeAllBefore(ctx context.Context, uid string, t time.Time) error {
+	if err := s.store.RevokeAllBefore(ctx, uid, t); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokedBefore[uid] = cachedTime{value: t, expiresAt: time.Now().Add(s.ttl)}
+	return nil
+}
+
+func (s *cachedStore) RevokedBefore(ctx context.Context, uid string) (time.Time, error) {
+	now := time.Now()
+	s.mu.RLock()
+	cached, ok := s.revokedBefore[uid]
+	s.mu.RUnlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	t, err := s.store.RevokedBefore(ctx, uid)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokedBefore[uid] = cachedTime{value: t, expiresAt: now.Add(s.ttl)}
+	return t, nil
+}
+
+// evictExpired keeps the maps small, it is called with the write lock held
+func (s *cachedStore) evictExpired(now time.Time) {
+	for jti, expiresAt := range s.revoked {
+		if now.After(expiresAt) {
+			delete(s.revoked, jti)
+		}
+	}
+	for jti, expiresAt := range s.notRevoked {
+		if now.After(expiresAt) {
+			delete(s.notRevoked, jti)
+		}
+	}
+	for uid, cached := range s.revokedBefore {
+		if now.After(cached.expiresAt) {
+			delete(s.revokedBefore, uid)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package mongodb
+
+import (
+	"context"
+	"emvn/consts"
+	"emvn/database/nosql"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Revocation store on top of NoSQLInterface
+// Revoked tokens are useless after expires_at, a TTL index on revoked_tokens.expires_at cleans them up
+type mongoStore struct {
+	noSqlDB nosql.NoSQLInterface
+}
+
+type revokedToken struct {
+	Jti       string    `bson:"jti"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+type sessionRevocation struct {
+	UserID        string    `bson:"user_id"`
+	RevokedBefore time.Time `bson:"revoked_before"`
+}
+
+func NewStore(noSqlDB nosql.NoSQLInterface) *mongoStore {
+	return &mongoStore{noSqlDB: noSqlDB}
+}
+
+func (s *mongoStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := s.noSqlDB.CreateIfNotExists(ctx, consts.MongoDBCollectionRevokedTokens, bson.M{"jti": jti}, revokedToken{
+		Jti:       jti,
+		ExpiresAt: expiresAt,
+	})
+	return err
+}
+
+func (s *mongoStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	count, err := s.noSqlDB.Count(ctx, consts.MongoDBCollectionRevokedTokens, bson.M{"jti": jti})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *mongoStore) RevokeAllBefore(ctx context.Context, uid string, t time.Time) error {
+	_, err := s.noSqlDB.CreateIfNotExists(ctx, consts.MongoDBCollectionSessionRevocations, bson.M{"user_id": uid}, sessionRevocation{
+		UserID:        uid,
+		RevokedBefore: t,
+	})
+	return err
+}
+
+func (s *mongoStore) RevokedBefore(ctx context.Context, uid string) (time.Time, error) {
+	result, err := s.noSqlDB.FindOne(ctx, consts.MongoDBCollectionSessionRevocations, bson.M{"user_id": uid})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+
+	var revocation sessionRevocation
+	if err := result.Decode(&revocation); err != nil {
+		return time.Time{}, err
+	}
+	return revocation.RevokedBefore, nil
+}
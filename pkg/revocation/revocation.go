@@ -0,0 +1,19 @@
+package revocation
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps the access tokens which have been revoked before their expiration
+// A token is revoked by its jti (logout), or all tokens of a user issued before a time are revoked (logout all sessions)
+// Like StorageInterface, the implementation can be switched, e.g. to Redis, without touching the auth usecase
+type Store interface {
+	// Revoke a token by its jti. expiresAt is the expiration of the token, the record is useless after it
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// RevokeAllBefore revokes every token of the user issued before t
+	RevokeAllBefore(ctx context.Context, uid string, t time.Time) error
+	// RevokedBefore returns the time set by RevokeAllBefore, zero time when the user never revoked all sessions
+	RevokedBefore(ctx context.Context, uid string) (time.Time, error)
+}
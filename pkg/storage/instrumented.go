@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"emvn/pkg/metrics"
+	"emvn/pkg/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// instrumentedStorage records the duration and the bytes of the operations of a storage, and traces them
+type instrumentedStorage struct {
+	next StorageInterface
+}
+
+// Instrument wraps the storage to record its operations, whatever the implementation
+func Instrument(next StorageInterface) StorageInterface {
+	return instrumentedStorage{next: next}
+}
+
+func (s instrumentedStorage) SaveFile(ctx context.Context, file []byte, fileName string) (string, error) {
+	ctx, span := tracing.Start(ctx, "storage.SaveFile")
+	defer span.End()
+	start := time.Now()
+	path, err := s.next.SaveFile(ctx, file, fileName)
+	metrics.ObserveStorage("save", time.Since(start), len(file), err)
+	span.SetAttributes(attribute.Int("storage.bytes", len(file)))
+	tracing.RecordError(span, err)
+	return path, err
+}
+
+func (s instrumentedStorage) GetFile(ctx context.Context, filePath string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "storage.GetFile")
+	defer span.End()
+	start := time.Now()
+	file, err := s.next.GetFile(ctx, filePath)
+	metrics.ObserveStorage("get", time.Since(start), len(file), err)
+	span.SetAttributes(attribute.Int("storage.bytes", len(file)))
+	tracing.RecordError(span, err)
+	return file, err
+}
+
+func (s instrumentedStorage) DeleteFile(ctx context.Context, filePath string) error {
+	ctx, span := tracing.Start(ctx, "storage.DeleteFile")
+	defer span.End()
+	start := time.Now()
+	err := s.next.DeleteFile(ctx, filePath)
+	metrics.ObserveStorage("delete", time.Since(start), 0, err)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s instrumentedStorage) Owns(filePath string) bool {
+	return s.next.Owns(filePath)
+}
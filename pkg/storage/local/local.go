@@ -1,11 +1,17 @@
 package local
 
 import (
-	"log/slog"
+	"context"
+	"emvn/pkg/logger"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// ErrOutsideStorage is returned for a path which is not a file of the storage directory
+var ErrOutsideStorage = errors.New("path is outside the storage directory")
+
 type localStorage struct {
 	Directory string
 }
@@ -25,24 +31,31 @@ func Storage() localStorage {
 	return localStorageClient
 }
 
-func (l localStorage) SaveFile(file []byte, fileName string) (string, error) {
-	filePath := l.Directory + "/" + fileName
+func (l localStorage) SaveFile(ctx context.Context, file []byte, fileName string) (string, error) {
+	filePath, err := l.resolve(filepath.Join(l.Directory, fileName))
+	if err != nil {
+		return "", err
+	}
 	emptyFile, err := os.Create(filePath)
 	if err != nil {
-		slog.Error(err.Error())
+		logger.FromContext(ctx).Error(err.Error())
 		return "", err
 	}
 	defer emptyFile.Close()
 	_, err = emptyFile.Write(file)
 	// err = os.WriteFile(dir, file, 0644)
 	if err != nil {
-		slog.Error(err.Error())
+		logger.FromContext(ctx).Error(err.Error())
 		return "", err
 	}
 	return filePath, nil
 }
 
-func (l localStorage) GetFile(filePath string) ([]byte, error) {
+func (l localStorage) GetFile(ctx context.Context, filePath string) ([]byte, error) {
+	filePath, err := l.resolve(filePath)
+	if err != nil {
+		return nil, err
+	}
 	file, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
@@ -50,10 +63,33 @@ func (l localStorage) GetFile(filePath string) ([]byte, error) {
 	return file, nil
 }
 
-func (l localStorage) DeleteFile(filePath string) error {
-	err := os.Remove(filePath)
+func (l localStorage) DeleteFile(ctx context.Context, filePath string) error {
+	filePath, err := l.resolve(filePath)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filePath)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+func (l localStorage) Owns(filePath string) bool {
+	_, err := l.resolve(filePath)
+	return err == nil
+}
+
+// resolve cleans the path and checks it is a file inside the storage directory,
+// so a path like ../config/config.yaml can not read or delete the other files of the server
+func (l localStorage) resolve(filePath string) (string, error) {
+	if !filepath.IsAbs(filePath) {
+		return "", ErrOutsideStorage
+	}
+	filePath = filepath.Clean(filePath)
+	rel, err := filepath.Rel(l.Directory, filePath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrOutsideStorage
+	}
+	return filePath, nil
+}
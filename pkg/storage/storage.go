@@ -1,5 +1,7 @@
 package storage
 
+import "context"
+
 // In my opinion, the music track mp3 file should be store on cloud storage like AWS S3, Google Cloud Storage, etc.
 // The metadata of the music track should be stored in a NoSQL database like MongoDB.
 // This storage package is the abstraction layer for the storage system.
@@ -8,9 +10,11 @@ package storage
 // When using cloud storage, we can easily switch the implementation by changing the implementation of this interface.
 type StorageInterface interface {
 	// SaveFile saves the file to the storage system and returns the file path or URL
-	SaveFile(file []byte, fileName string) (string, error)
+	SaveFile(ctx context.Context, file []byte, fileName string) (string, error)
 	// GetFile gets the file from the storage system by the file path or URL
-	GetFile(filePath string) ([]byte, error)
+	GetFile(ctx context.Context, filePath string) ([]byte, error)
 	// DeleteFile deletes the file from the storage system by the file path or URL
-	DeleteFile(filePath string) error
+	DeleteFile(ctx context.Context, filePath string) error
+	// Owns reports whether the path or URL is a file of the storage system, the others are refused by GetFile and DeleteFile
+	Owns(filePath string) bool
 }
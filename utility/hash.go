@@ -1,34 +1,45 @@
 package utility
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"emvn/config"
 	"emvn/consts"
+	"emvn/pkg/keyset"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"fmt"
 	"log/slog"
-	"math/rand"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var ConfigJWTHashFunction = jwt.SigningMethodHS256
+var jwtKeys *keyset.KeySet
+
+// InitJWTKeys loads the keys signing and verifying the JWT from the auth config
+func InitJWTKeys(cfg config.AuthConfig) error {
+	keys, err := keyset.Load(cfg.KeyDir, cfg.SigningKeyID, cfg.SecretKey)
+	if err != nil {
+		return err
+	}
+	jwtKeys = keys
+	return nil
+}
+
+// JWKS returns the public keys verifying the JWT
+func JWKS() keyset.JWKS {
+	return jwtKeys.JWKS()
+}
 
 // Gen JWT
 // Args: payload jwt map claims
 func GenJWT(payload jwt.MapClaims) (token string, err error) {
-	tokenJWT := jwt.NewWithClaims(ConfigJWTHashFunction, payload)
-	token, err = tokenJWT.SignedString([]byte(config.GetConfig().Auth.SecretKey))
-	return
+	return jwtKeys.Sign(payload)
 }
 
 // Validate JWT token
 func ParseJWT(tokenString string) (jwt.MapClaims, error) {
-	tokenParse, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(config.GetConfig().Auth.SecretKey), nil
-	})
+	tokenParse, err := jwt.Parse(tokenString, jwtKeys.Keyfunc)
 
 	var claims jwt.MapClaims
 
@@ -50,32 +61,18 @@ func ParseJWT(tokenString string) (jwt.MapClaims, error) {
 	}
 }
 
-func GenerateRandomPassword() string {
-	// Define character sets
-	uppercaseLetters := "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	lowercaseLetters := "abcdefghijklmnopqrstuvwxyz"
-	digits := "0123456789"
-	specialCharacters := "@"
-
-	// Combine character sets
-	allCharacters := uppercaseLetters + lowercaseLetters + digits + specialCharacters
-
-	// Generate a password
-	var passwordBuilder []rune
-
-	// Add special char
-	specialCharIndex := rand.Intn(len(specialCharacters))
-	passwordBuilder = append(passwordBuilder, []rune(specialCharacters)[specialCharIndex])
-
-	for i := 0; i < 8; i++ {
-		// Randomly select a character from allCharacters
-		charIndex := rand.Intn(len(allCharacters))
-		passwordBuilder = append(passwordBuilder, []rune(allCharacters)[charIndex])
+// GenerateRandomToken returns a url-safe random string built from n bytes of crypto/rand
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	// Add number char
-	numberCharIndex := rand.Intn(len(digits))
-	passwordBuilder = append(passwordBuilder, []rune(digits)[numberCharIndex])
-
-	return string(passwordBuilder)
+// HashToken hashes a random token before storing it, so a leaked database does not leak usable tokens
+// Tokens have enough entropy, a fast hash is enough
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }